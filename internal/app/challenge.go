@@ -0,0 +1,179 @@
+package app
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func (a *App) GetChallengeById(officeId uint, id string) (*db.Challenge, error) {
+	challenge := &db.Challenge{}
+	err := a.db.C.Where("office_id = ?", officeId).
+		Preload("Game").
+		Preload("Challenger").
+		Preload("Opponent").
+		Preload("Match.Participants.User").
+		Preload("Match.Scores", orderScores).
+		First(challenge, "id = ?", id).Error
+	if err != nil {
+		if db.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// GetOutstandingGameChallenges returns the game's challenges that are waiting on
+// a response or to be played, soonest deadline first.
+func (a *App) GetOutstandingGameChallenges(game *db.Game) ([]db.Challenge, error) {
+	challenges := []db.Challenge{}
+	err := a.db.C.Where("game_id = ? AND state IN ?", game.ID, []string{db.ChallengeStateOpen, db.ChallengeStateAccepted}).
+		Where("deadline IS NULL OR deadline > ?", time.Now()).
+		Order("deadline IS NULL, deadline, created_at").
+		Preload("Game").
+		Preload("Challenger").
+		Preload("Opponent").
+		Find(&challenges).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return challenges, nil
+}
+
+func (a *App) CreateChallenge(challenger *db.User, office *db.Office, game *db.Game, opponentId string, deadline *time.Time, note string) (error, error) {
+	opponentIdInt, err := strconv.Atoi(opponentId)
+	if err != nil {
+		return errors.New("Pick someone to challenge"), nil
+	}
+
+	var opponent *db.User
+	for _, player := range office.Players {
+		if player.ID == uint(opponentIdInt) {
+			opponent = &player
+		}
+	}
+	if opponent == nil || opponent.NonPlayer {
+		return errors.New("Pick someone to challenge"), nil
+	}
+	if opponent.ID == challenger.ID {
+		return errors.New("You can't challenge yourself"), nil
+	}
+	if deadline != nil && deadline.Before(time.Now()) {
+		return errors.New("The deadline must be in the future"), nil
+	}
+
+	challenge := db.Challenge{
+		OfficeID:     office.ID,
+		GameID:       game.ID,
+		ChallengerID: challenger.ID,
+		OpponentID:   opponent.ID,
+		Deadline:     deadline,
+		Note:         note,
+	}
+	err = a.db.C.Create(&challenge).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (a *App) RespondToChallenge(user *db.User, challenge *db.Challenge, accept bool) error {
+	if user.ID != challenge.OpponentID {
+		return errors.New("Only the player challenged can respond")
+	}
+	if challenge.State != db.ChallengeStateOpen || challenge.IsExpired() {
+		return errors.New("This challenge is no longer open")
+	}
+
+	if accept {
+		return a.db.C.Model(challenge).Update("state", db.ChallengeStateAccepted).Error
+	}
+	if !challenge.Ladder {
+		return a.db.C.Model(challenge).Update("state", db.ChallengeStateDeclined).Error
+	}
+
+	// Refusing a ladder challenge forfeits the place to the challenger
+	tx := a.db.C.Begin()
+	err := tx.Model(challenge).Update("state", db.ChallengeStateDeclined).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = swapLadderPositions(tx, *challenge)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// LogChallengeMatch logs the result of an accepted challenge as a match between
+// the two players, and links the challenge to it.
+func (a *App) LogChallengeMatch(user *db.User, office *db.Office, challenge *db.Challenge, winnerId string, bestOf int, scores []db.GameScore) (*db.Match, error) {
+	if !challenge.Involves(user.ID) {
+		return nil, errors.New("Only the players in the challenge can log its result")
+	}
+	if challenge.State != db.ChallengeStateAccepted || challenge.IsExpired() {
+		return nil, errors.New("Only accepted challenges can be played")
+	}
+
+	challengerId := strconv.Itoa(int(challenge.ChallengerID))
+	opponentId := strconv.Itoa(int(challenge.OpponentID))
+	details := MatchDetails{
+		GameID:  challenge.GameID,
+		Note:    challenge.Note,
+		Winners: []string{challengerId},
+		Losers:  []string{opponentId},
+		BestOf:  bestOf,
+		Scores:  scores,
+	}
+	switch winnerId {
+	case challengerId:
+	case opponentId:
+		details.Winners, details.Losers = details.Losers, details.Winners
+	default:
+		return nil, errors.New("The winner must be one of the players in the challenge")
+	}
+
+	tx := a.db.C.Begin()
+
+	match, err := logMatch(tx, user, office, details, nil)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	result := tx.Model(&db.Challenge{}).
+		Where("id = ? AND state = ? AND match_id IS NULL", challenge.ID, db.ChallengeStateAccepted).
+		Updates(map[string]interface{}{
+			"match_id": match.ID,
+			"state":    db.ChallengeStatePlayed,
+		})
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// The other player got there first, or it lapsed
+		tx.Rollback()
+		return nil, errors.New("This challenge has already been played")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, err
+	}
+
+	challenge.MatchID = &match.ID
+	challenge.State = db.ChallengeStatePlayed
+	return match, nil
+}
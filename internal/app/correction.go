@@ -0,0 +1,141 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"gorm.io/gorm"
+)
+
+// GetMatchCorrections returns the changes made to the match after it was
+// approved, newest first.
+func (a *App) GetMatchCorrections(matchId uint) ([]db.MatchCorrection, error) {
+	corrections := []db.MatchCorrection{}
+	err := a.db.C.Where("match_id = ?", matchId).
+		Order("created_at DESC").
+		Preload("Admin").
+		Find(&corrections).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return corrections, nil
+}
+
+// EditApprovedMatch replaces the result of an approved match, keeping the
+// original in an audit record. The game is replayed so every rating after the
+// match is worked out again. Tournament and ladder results that followed from
+// the original result stand.
+func (a *App) EditApprovedMatch(admin *db.User, match *db.Match, details MatchDetails) (error, error) {
+	if match.State != db.MatchStateApproved {
+		return errors.New("Only approved matches can be edited"), nil
+	}
+
+	bestOf := 0
+	if len(details.Scores) > 0 {
+		bestOf = details.BestOf
+	}
+
+	err := a.correctMatch(admin, match, db.MatchCorrectionEdited, func(tx *gorm.DB) error {
+		err := tx.Where("match_id = ?", match.ID).Delete(&db.MatchParticipant{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("match_id = ?", match.ID).Delete(&db.GameScore{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = createMatchResult(tx, match.ID, details)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&db.Match{}).Where("id = ?", match.ID).Updates(map[string]interface{}{
+			"note":        details.Note,
+			"is_handicap": details.IsHandicap,
+			"best_of":     bestOf,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// VoidApprovedMatch takes an approved match out of the rankings, keeping it in
+// an audit record. The game is replayed so every rating after the match is
+// worked out again.
+func (a *App) VoidApprovedMatch(admin *db.User, match *db.Match) (error, error) {
+	if match.State != db.MatchStateApproved {
+		return errors.New("Only approved matches can be voided"), nil
+	}
+
+	err := a.correctMatch(admin, match, db.MatchCorrectionVoided, func(tx *gorm.DB) error {
+		return tx.Model(&db.Match{}).Where("id = ?", match.ID).Update("state", db.MatchStateVoided).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// correctMatch records the match as it stands, applies the admin's change and
+// replays the game.
+func (a *App) correctMatch(admin *db.User, match *db.Match, action string, change func(tx *gorm.DB) error) error {
+	tx := a.db.C.Begin()
+
+	correction := db.MatchCorrection{
+		MatchID:         match.ID,
+		AdminID:         admin.ID,
+		Action:          action,
+		OriginalWinners: participantNames(match.Winners()),
+		OriginalLosers:  participantNames(match.Losers()),
+		OriginalScores:  match.ScoreSummary(),
+		OriginalNote:    match.Note,
+	}
+	err := tx.Create(&correction).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = change(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Model(&db.Match{}).Where("id = ?", match.ID).Update("corrected_at", time.Now()).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = clearSeasonStandings(tx, match)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return err
+	}
+
+	_, err = a.gp.Replay(match.GameID)
+	return err
+}
+
+func participantNames(participants []db.MatchParticipant) string {
+	names := []string{}
+	for _, participant := range participants {
+		names = append(names, participant.User.Username)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"gorm.io/gorm"
+)
+
+// GetDisputedMatches returns the game's matches waiting on the admin to settle a
+// dispute, oldest first so they're dealt with in order.
+func (a *App) GetDisputedMatches(game *db.Game) ([]db.Match, error) {
+	matches := []db.Match{}
+	err := a.db.C.Where("game_id = ? AND state = ?", game.ID, db.MatchStateDisputed).
+		Order("created_at").
+		Preload("Game").
+		Preload("Participants.User").
+		Preload("Creator").
+		Preload("Approvals").
+		Preload("Scores", orderScores).
+		Preload("DisputedBy").
+		Find(&matches).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// DisputeMatch takes a pending match out of the approval flow until the office
+// admin has looked at it. Only the players in the match can dispute it.
+func (a *App) DisputeMatch(user *db.User, match *db.Match, reason string) (error, error) {
+	if match.State != db.MatchStatePending {
+		return errors.New("Only pending matches can be disputed"), nil
+	}
+	if match.CreatorID == user.ID {
+		return errors.New("You logged this match, delete it instead"), nil
+	}
+
+	if !match.HasParticipant(user.ID) {
+		return errors.New("Only players in the match can dispute it"), nil
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("Say what's wrong with the result"), nil
+	}
+
+	result := a.db.C.Model(&db.Match{}).Where("id = ? AND state = ?", match.ID, db.MatchStatePending).Updates(map[string]interface{}{
+		"state":          db.MatchStateDisputed,
+		"dispute_reason": reason,
+		"disputed_by_id": user.ID,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Approved or expired since it was read
+		return errMatchSettled, nil
+	}
+
+	match.State = db.MatchStateDisputed
+	match.DisputeReason = reason
+	match.DisputedByID = &user.ID
+
+	return nil, nil
+}
+
+// ApproveDisputedMatch settles a dispute in favour of the result as it was
+// logged.
+func (a *App) ApproveDisputedMatch(admin *db.User, match *db.Match) error {
+	return a.settleDispute(admin, match, func(tx *gorm.DB) error {
+		return nil
+	})
+}
+
+// CorrectDisputedMatch settles a dispute by fixing the result before approving
+// it. The sides are swapped if the logged losers actually won, and the scores
+// replace the logged ones, with the corrected winners' score first.
+func (a *App) CorrectDisputedMatch(admin *db.User, match *db.Match, swapSides bool, bestOf int, scores []db.GameScore) error {
+	return a.settleDispute(admin, match, func(tx *gorm.DB) error {
+		if swapSides {
+			err := tx.Model(&db.MatchParticipant{}).Where("match_id = ?", match.ID).
+				Update("result", gorm.Expr("CASE WHEN result = ? THEN ? ELSE ? END", db.MatchResultWin, db.MatchResultLoss, db.MatchResultWin)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tx.Where("match_id = ?", match.ID).Delete(&db.GameScore{}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(scores) == 0 {
+			bestOf = 0
+		} else {
+			newScores := []db.GameScore{}
+			for _, score := range scores {
+				score.MatchID = match.ID
+				newScores = append(newScores, score)
+			}
+
+			err = tx.Create(&newScores).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&db.Match{}).Where("id = ?", match.ID).Update("best_of", bestOf).Error
+	})
+}
+
+// VoidDisputedMatch settles a dispute by throwing the match away, as if it was
+// never logged.
+func (a *App) VoidDisputedMatch(match *db.Match) error {
+	if match.State != db.MatchStateDisputed {
+		return errors.New("match is not disputed")
+	}
+
+	return a.db.C.Delete(match).Error
+}
+
+// settleDispute applies the admin's correction to the disputed match and
+// approves it on their behalf.
+func (a *App) settleDispute(admin *db.User, match *db.Match, correct func(tx *gorm.DB) error) error {
+	if match.State != db.MatchStateDisputed {
+		return errors.New("match is not disputed")
+	}
+
+	tx := a.db.C.Begin()
+
+	err := correct(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if !match.IsApprovedByUser(admin.ID) {
+		approval := db.MatchApproval{
+			MatchID: match.ID,
+			UserID:  admin.ID,
+		}
+		err = tx.Create(&approval).Error
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = a.processApprovedMatch(tx, match, db.MatchStateDisputed)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return err
+	}
+
+	a.applyApprovedMatch(match)
+	return nil
+}
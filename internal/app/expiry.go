@@ -0,0 +1,180 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+// Players are warned this long before a pending match is settled for them. A
+// match is never settled any sooner after the warning, even if it's already
+// past the office's approval window.
+const pendingMatchWarningDays = 2
+
+// PendingMatchSettlesAt is when the match will be approved or expired if it's
+// still pending, nil if the office lets matches wait forever.
+func PendingMatchSettlesAt(office db.Office, match db.Match) *time.Time {
+	if office.PendingMatches.Days == 0 {
+		return nil
+	}
+
+	settlesAt := match.CreatedAt.AddDate(0, 0, office.PendingMatches.Days)
+	if match.ExpiryWarnedAt != nil {
+		warningEnds := match.ExpiryWarnedAt.AddDate(0, 0, pendingMatchWarningDays)
+		if warningEnds.After(settlesAt) {
+			settlesAt = warningEnds
+		}
+	}
+
+	return &settlesAt
+}
+
+// WarnStalePendingMatches marks the pending matches that are about to be
+// settled as warned, and returns them so their players can be told.
+func (a *App) WarnStalePendingMatches() ([]db.Match, error) {
+	offices, err := a.officesSettlingPendingMatches()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	warned := []db.Match{}
+	for _, office := range offices {
+		cutoff := now.AddDate(0, 0, pendingMatchWarningDays-office.PendingMatches.Days)
+
+		matches := []db.Match{}
+		err = a.db.C.Where("office_id = ? AND state = ? AND expiry_warned_at IS NULL AND created_at < ?", office.ID, db.MatchStatePending, cutoff).
+			Preload("Office").
+			Preload("Game").
+			Preload("Creator").
+			Preload("Participants.User").
+			Preload("Approvals").
+			Find(&matches).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		matchIds := []uint{}
+		for i := range matches {
+			matchIds = append(matchIds, matches[i].ID)
+			matches[i].ExpiryWarnedAt = &now
+		}
+		err = a.db.C.Model(&db.Match{}).Where("id IN ?", matchIds).Update("expiry_warned_at", now).Error
+		if err != nil {
+			return nil, err
+		}
+
+		warned = append(warned, matches...)
+	}
+
+	return warned, nil
+}
+
+// SettleStalePendingMatches approves or expires the pending matches that have
+// been left past their office's approval window, oldest first.
+func (a *App) SettleStalePendingMatches() error {
+	offices, err := a.officesSettlingPendingMatches()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	warningCutoff := now.AddDate(0, 0, -pendingMatchWarningDays)
+	for _, office := range offices {
+		cutoff := now.AddDate(0, 0, -office.PendingMatches.Days)
+
+		matches := []db.Match{}
+		err = a.db.C.Where("office_id = ? AND state = ? AND created_at < ? AND expiry_warned_at < ?", office.ID, db.MatchStatePending, cutoff, warningCutoff).
+			Order("created_at").
+			Preload("Office").
+			Preload("Participants").
+			Preload("Approvals").
+			Find(&matches).Error
+		if err != nil {
+			return err
+		}
+
+		for _, match := range matches {
+			if match.IsApprovedOnExpiry() {
+				err = a.autoApproveMatch(&match)
+			} else {
+				err = a.expireMatch(&match)
+			}
+			if errors.Is(err, errMatchSettled) {
+				// Approved or disputed since it was read, so it's no longer stale
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *App) officesSettlingPendingMatches() ([]db.Office, error) {
+	offices := []db.Office{}
+	err := a.db.C.Where("pending_match_days > 0").Find(&offices).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return offices, nil
+}
+
+func (a *App) autoApproveMatch(match *db.Match) error {
+	tx := a.db.C.Begin()
+
+	err := a.processApprovedMatch(tx, match, db.MatchStatePending)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return err
+	}
+
+	a.applyApprovedMatch(match)
+	return nil
+}
+
+// expireMatch marks the match expired, so it never counts but its players can
+// still see what happened to it. Any challenge or tournament fixture it was the
+// result of is back on.
+func (a *App) expireMatch(match *db.Match) error {
+	tx := a.db.C.Begin()
+
+	result := tx.Model(&db.Match{}).Where("id = ? AND state = ?", match.ID, db.MatchStatePending).Update("state", db.MatchStateExpired)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return errMatchSettled
+	}
+
+	err := tx.Model(&db.TournamentFixture{}).Where("match_id = ?", match.ID).Update("match_id", nil).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Model(&db.Challenge{}).Where("match_id = ?", match.ID).Updates(map[string]interface{}{
+		"match_id": nil,
+		"state":    db.ChallengeStateAccepted,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
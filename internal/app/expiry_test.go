@@ -0,0 +1,93 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func TestPendingMatchSettlesAt(t *testing.T) {
+	created := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	at := func(when time.Time) *time.Time {
+		return &when
+	}
+
+	tests := []struct {
+		name     string
+		days     int
+		warnedAt *time.Time
+		want     *time.Time
+	}{
+		{name: "waits forever", days: 0},
+		{name: "not warned yet", days: 7, want: at(created.AddDate(0, 0, 7))},
+		{name: "warned on time", days: 7, warnedAt: at(created.AddDate(0, 0, 5)), want: at(created.AddDate(0, 0, 7))},
+		{name: "warned late", days: 7, warnedAt: at(created.AddDate(0, 0, 10)), want: at(created.AddDate(0, 0, 12))},
+		{name: "window shorter than the warning", days: 1, warnedAt: at(created), want: at(created.AddDate(0, 0, 2))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			office := db.Office{PendingMatches: db.PendingMatchSettings{Days: tt.days}}
+			match := db.Match{ExpiryWarnedAt: tt.warnedAt}
+			match.CreatedAt = created
+
+			got := PendingMatchSettlesAt(office, match)
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsApprovedOnExpiry(t *testing.T) {
+	const (
+		creatorId = 1
+		winnerId  = 2
+		loserId   = 3
+		adminId   = 4
+	)
+
+	tests := []struct {
+		name        string
+		autoApprove bool
+		// Whether the creator played, on the winning side
+		creatorPlayed bool
+		approvedBy    []uint
+		want          bool
+	}{
+		{name: "nobody approved", autoApprove: true, creatorPlayed: true, want: false},
+		{name: "creator's side approved", autoApprove: true, creatorPlayed: true, approvedBy: []uint{creatorId}, want: true},
+		{name: "creator's side approved without auto approve", creatorPlayed: true, approvedBy: []uint{creatorId}, want: false},
+		{name: "only the other side approved", autoApprove: true, creatorPlayed: true, approvedBy: []uint{loserId}, want: false},
+		{name: "both sides approved", creatorPlayed: true, approvedBy: []uint{creatorId, loserId}, want: true},
+		{name: "admin approved", approvedBy: []uint{adminId}, want: true},
+		{name: "creator didn't play", autoApprove: true, approvedBy: []uint{winnerId}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := db.Match{
+				CreatorID: creatorId,
+				Office: db.Office{
+					AdminRefer:     adminId,
+					PendingMatches: db.PendingMatchSettings{AutoApprove: tt.autoApprove},
+				},
+				Participants: []db.MatchParticipant{
+					{UserID: winnerId, Result: db.MatchResultWin},
+					{UserID: loserId, Result: db.MatchResultLoss},
+				},
+			}
+			if tt.creatorPlayed {
+				match.Participants = append(match.Participants, db.MatchParticipant{UserID: creatorId, Result: db.MatchResultWin})
+			}
+			for _, userId := range tt.approvedBy {
+				match.Approvals = append(match.Approvals, db.MatchApproval{UserID: userId})
+			}
+
+			if got := match.IsApprovedOnExpiry(); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package app
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"gorm.io/gorm"
+)
+
+// GetLadder returns the game's ladder from the top down.
+func (a *App) GetLadder(game *db.Game) ([]db.LadderPosition, error) {
+	positions := []db.LadderPosition{}
+	err := a.db.C.Where("game_id = ?", game.ID).
+		Preload("User").
+		Order("position").
+		Find(&positions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// GetOutstandingLadderChallenges returns the game's ladder challenges waiting on
+// a response or to be played.
+func (a *App) GetOutstandingLadderChallenges(game *db.Game) ([]db.Challenge, error) {
+	challenges, err := a.GetOutstandingGameChallenges(game)
+	if err != nil {
+		return nil, err
+	}
+
+	ladderChallenges := []db.Challenge{}
+	for _, challenge := range challenges {
+		if challenge.Ladder {
+			ladderChallenges = append(ladderChallenges, challenge)
+		}
+	}
+
+	return ladderChallenges, nil
+}
+
+// JoinLadder puts the player on the bottom rung of the game's ladder.
+func (a *App) JoinLadder(user *db.User, game *db.Game) (error, error) {
+	if !game.Ladder.Enabled {
+		return errors.New("This game doesn't have a ladder"), nil
+	}
+	if user.NonPlayer {
+		return errors.New("Non-players can't join the ladder"), nil
+	}
+
+	var count int64
+	err := a.db.C.Model(&db.LadderPosition{}).
+		Where("game_id = ? AND user_id = ?", game.ID, user.ID).
+		Count(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return errors.New("You're already on the ladder"), nil
+	}
+
+	var bottom int
+	err = a.db.C.Model(&db.LadderPosition{}).
+		Where("game_id = ?", game.ID).
+		Select("COALESCE(MAX(position), 0)").
+		Scan(&bottom).Error
+	if err != nil {
+		return nil, err
+	}
+
+	position := db.LadderPosition{
+		OfficeID: game.OfficeID,
+		GameID:   game.ID,
+		UserID:   user.ID,
+		Position: bottom + 1,
+	}
+	err = a.db.C.Create(&position).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// CreateLadderChallenge challenges a player at most the game's ladder reach
+// places above the challenger. The opponent has the game's ladder challenge days
+// to accept and play it.
+func (a *App) CreateLadderChallenge(challenger *db.User, game *db.Game, opponentId string) (error, error) {
+	if !game.Ladder.Enabled {
+		return errors.New("This game doesn't have a ladder"), nil
+	}
+
+	positions, err := a.GetLadder(game)
+	if err != nil {
+		return nil, err
+	}
+
+	var challengerPosition, opponentPosition *db.LadderPosition
+	for _, position := range positions {
+		if position.UserID == challenger.ID {
+			challengerPosition = &position
+		}
+		if strconv.Itoa(int(position.UserID)) == opponentId {
+			opponentPosition = &position
+		}
+	}
+	if challengerPosition == nil {
+		return errors.New("Join the ladder to challenge someone on it"), nil
+	}
+	if opponentPosition == nil {
+		return errors.New("Pick someone on the ladder to challenge"), nil
+	}
+	if opponentPosition.Position >= challengerPosition.Position {
+		return errors.New("You can only challenge players above you"), nil
+	}
+	if challengerPosition.Position-opponentPosition.Position > game.Ladder.Reach {
+		return errors.New("That player is too far up the ladder"), nil
+	}
+
+	challenges, err := a.GetOutstandingLadderChallenges(game)
+	if err != nil {
+		return nil, err
+	}
+	for _, challenge := range challenges {
+		if challenge.Involves(challenger.ID) {
+			return errors.New("You're already in a ladder challenge"), nil
+		}
+		if challenge.Involves(opponentPosition.UserID) {
+			return errors.New("That player is already in a ladder challenge"), nil
+		}
+	}
+
+	deadline := time.Now().AddDate(0, 0, game.Ladder.ChallengeDays)
+	challenge := db.Challenge{
+		OfficeID:     game.OfficeID,
+		GameID:       game.ID,
+		ChallengerID: challenger.ID,
+		OpponentID:   opponentPosition.UserID,
+		Deadline:     &deadline,
+		Ladder:       true,
+	}
+	err = a.db.C.Create(&challenge).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// SettleExpiredLadderChallenges settles the ladder challenges that are past their
+// deadline. Not answering in time is treated the same as refusing, the
+// challenger takes the opponent's place. An accepted challenge that wasn't
+// played in time lapses, and the ladder stays as it is.
+func (a *App) SettleExpiredLadderChallenges() error {
+	challenges := []db.Challenge{}
+	err := a.db.C.Where("ladder AND state IN ? AND deadline < ?", []string{db.ChallengeStateOpen, db.ChallengeStateAccepted}, time.Now()).
+		Order("deadline").
+		Find(&challenges).Error
+	if err != nil {
+		return err
+	}
+
+	for _, challenge := range challenges {
+		err = a.settleExpiredLadderChallenge(challenge)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *App) settleExpiredLadderChallenge(challenge db.Challenge) error {
+	newState := db.ChallengeStateLapsed
+	if challenge.State == db.ChallengeStateOpen {
+		newState = db.ChallengeStateForfeited
+	}
+
+	tx := a.db.C.Begin()
+
+	// The opponent may have responded, or the match been logged, since it was read
+	result := tx.Model(&db.Challenge{}).
+		Where("id = ? AND state = ?", challenge.ID, challenge.State).
+		Update("state", newState)
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil
+	}
+
+	if newState == db.ChallengeStateForfeited {
+		err := swapLadderPositions(tx, challenge)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// completeLadderChallenge moves the challenger up the ladder if they won the
+// approved match of a ladder challenge.
+func (a *App) completeLadderChallenge(tx *gorm.DB, match *db.Match) error {
+	challenge := db.Challenge{}
+	err := tx.Where("match_id = ? AND ladder", match.ID).First(&challenge).Error
+	if err != nil {
+		if db.IsRecordNotFoundError(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	var challengerWins int64
+	err = tx.Model(&db.MatchParticipant{}).
+		Where("match_id = ? AND user_id = ? AND result = ?", match.ID, challenge.ChallengerID, db.MatchResultWin).
+		Count(&challengerWins).Error
+	if err != nil {
+		return err
+	}
+	if challengerWins == 0 {
+		return nil
+	}
+
+	return swapLadderPositions(tx, challenge)
+}
+
+// swapLadderPositions swaps the challenger with the player they challenged, as
+// long as the challenger is still below them.
+func swapLadderPositions(tx *gorm.DB, challenge db.Challenge) error {
+	positions := []db.LadderPosition{}
+	err := tx.Where("game_id = ? AND user_id IN ?", challenge.GameID, []uint{challenge.ChallengerID, challenge.OpponentID}).
+		Find(&positions).Error
+	if err != nil {
+		return err
+	}
+	if len(positions) != 2 {
+		return nil
+	}
+
+	challenger, opponent := positions[0], positions[1]
+	if challenger.UserID != challenge.ChallengerID {
+		challenger, opponent = opponent, challenger
+	}
+	challengerPosition, opponentPosition := challenger.Position, opponent.Position
+	if challengerPosition < opponentPosition {
+		return nil
+	}
+
+	err = tx.Model(&challenger).Update("position", opponentPosition).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&opponent).Update("position", challengerPosition).Error
+}
@@ -2,7 +2,10 @@ package app
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/RowMur/office-table-tennis/internal/db"
 	"gorm.io/gorm"
@@ -11,9 +14,12 @@ import (
 func (a *App) GetMatchById(id string) (*db.Match, error) {
 	match := db.Match{}
 	err := a.db.C.Preload("Office").
+		Preload("Game").
 		Preload("Participants.User").
 		Preload("Creator").
 		Preload("Approvals").
+		Preload("Scores", orderScores).
+		Preload("DisputedBy").
 		First(&match, "id = ?", id).Error
 	if err != nil {
 		return nil, err
@@ -22,54 +28,146 @@ func (a *App) GetMatchById(id string) (*db.Match, error) {
 	return &match, nil
 }
 
-func (a *App) LogMatch(creator *db.User, office *db.Office, note string, winners, losers []string, isHandicap bool) (*db.Match, error) {
+func orderScores(db *gorm.DB) *gorm.DB {
+	return db.Order("number")
+}
+
+type MatchDetails struct {
+	GameID     uint
+	Note       string
+	Winners    []string
+	Losers     []string
+	IsHandicap bool
+	BestOf     int
+	Scores     []db.GameScore
+	// When the match was played, now if it's zero
+	PlayedAt time.Time
+}
+
+// Matches can be logged at most this long after they were played
+const playedAtMaxDaysBack = 7
+
+// playedAtLayout is how the play form's datetime-local input sends the time
+const playedAtLayout = "2006-01-02T15:04"
+
+// Furthest a UTC offset can be from UTC, in minutes
+const maxTimezoneOffset = 14 * 60
+
+// ParsePlayedAt reads when a match was played from the play form, in the
+// timezone of the player's browser. tzOffset is the browser's UTC offset in
+// minutes, as Date's getTimezoneOffset gives it, so positive west of UTC. No
+// time means it's just been played. It can't be in the future or too far in the
+// past.
+func ParsePlayedAt(input string, tzOffset string) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return time.Time{}, nil
+	}
+
+	offset, err := strconv.Atoi(strings.TrimSpace(tzOffset))
+	if err != nil || offset < -maxTimezoneOffset || offset > maxTimezoneOffset {
+		return time.Time{}, errors.New("Couldn't tell which timezone the match was played in")
+	}
+
+	playedAt, err := time.ParseInLocation(playedAtLayout, input, time.FixedZone("", -offset*60))
+	if err != nil {
+		return time.Time{}, errors.New("When the match was played isn't a valid time")
+	}
+	if playedAt.After(time.Now()) {
+		return time.Time{}, errors.New("The match can't have been played in the future")
+	}
+	if playedAt.Before(time.Now().AddDate(0, 0, -playedAtMaxDaysBack)) {
+		return time.Time{}, fmt.Errorf("Matches can only be logged up to %d days after they were played", playedAtMaxDaysBack)
+	}
+
+	return playedAt, nil
+}
+
+func (a *App) LogMatch(creator *db.User, office *db.Office, details MatchDetails) (*db.Match, error) {
 	tx := a.db.C.Begin()
 
+	match, err := logMatch(tx, creator, office, details, nil)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	tx.Commit()
+	return match, nil
+}
+
+// logMatch creates the match as part of the session, if there is one.
+func logMatch(tx *gorm.DB, creator *db.User, office *db.Office, details MatchDetails, sessionId *uint) (*db.Match, error) {
+	bestOf := 0
+	if len(details.Scores) > 0 {
+		bestOf = details.BestOf
+	}
+
 	match := db.Match{
 		OfficeID:   office.ID,
+		GameID:     details.GameID,
 		CreatorID:  creator.ID,
-		Note:       note,
-		IsHandicap: isHandicap,
+		Note:       details.Note,
+		IsHandicap: details.IsHandicap,
+		BestOf:     bestOf,
+		PlayedAt:   details.PlayedAt,
+		SessionID:  sessionId,
 	}
 	if err := tx.Create(&match).Error; err != nil {
-		tx.Rollback()
 		return nil, err
 	}
 
+	err := createMatchResult(tx, match.ID, details)
+	if err != nil {
+		return nil, err
+	}
+
+	return &match, nil
+}
+
+// createMatchResult records who won and lost the match, and the scores if there
+// are any.
+func createMatchResult(tx *gorm.DB, matchId uint, details MatchDetails) error {
 	participants := []db.MatchParticipant{}
-	for _, winner := range winners {
+	for _, winner := range details.Winners {
 		userId, err := strconv.Atoi(winner)
 		if err != nil {
-			tx.Rollback()
-			return nil, err
+			return err
 		}
 		participants = append(participants, db.MatchParticipant{
 			UserID:  uint(userId),
-			MatchID: match.ID,
+			MatchID: matchId,
 			Result:  db.MatchResultWin,
 		})
 	}
-	for _, loser := range losers {
+	for _, loser := range details.Losers {
 		userId, err := strconv.Atoi(loser)
 		if err != nil {
-			tx.Rollback()
-			return nil, err
+			return err
 		}
 		participants = append(participants, db.MatchParticipant{
 			UserID:  uint(userId),
-			MatchID: match.ID,
+			MatchID: matchId,
 			Result:  db.MatchResultLoss,
 		})
 	}
 
 	err := tx.Create(&participants).Error
 	if err != nil {
-		tx.Rollback()
-		return nil, err
+		return err
 	}
 
-	tx.Commit()
-	return &match, nil
+	if len(details.Scores) == 0 {
+		return nil
+	}
+
+	scores := []db.GameScore{}
+	for _, score := range details.Scores {
+		score.MatchID = matchId
+		scores = append(scores, score)
+	}
+
+	return tx.Create(&scores).Error
 }
 
 func (a *App) ApproveMatch(user *db.User, match *db.Match) error {
@@ -108,16 +206,39 @@ func (a *App) ApproveMatch(user *db.User, match *db.Match) error {
 		return nil
 	}
 
-	err = a.processApprovedMatch(tx, match)
+	err = a.processApprovedMatch(tx, match, db.MatchStatePending)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	tx.Commit()
+	err = tx.Commit().Error
+	if err != nil {
+		return err
+	}
+
+	a.applyApprovedMatch(match)
 	return nil
 }
 
+// applyApprovedMatch brings everything that depends on the rankings up to date
+// once the match's approval has been committed. The approval stands either way,
+// so errors are only logged.
+func (a *App) applyApprovedMatch(match *db.Match) {
+	err := a.gp.ApplyApprovedMatch(match.ID)
+	if err != nil {
+		fmt.Printf("Error applying approved match %d: %v\n", match.ID, err)
+
+		// Don't leave the cache without the match
+		a.gp.InvalidateGameCache(match.GameID)
+	}
+
+	err = a.startNextSwissRound(match)
+	if err != nil {
+		fmt.Printf("Error starting the next Swiss round after match %d: %v\n", match.ID, err)
+	}
+}
+
 func (a *App) IsMatchApproved(tx *gorm.DB, match *db.Match) (bool, error) {
 	err := tx.Preload("Office").
 		Preload("Participants").
@@ -130,18 +251,38 @@ func (a *App) IsMatchApproved(tx *gorm.DB, match *db.Match) (bool, error) {
 	return match.IsApproved(), nil
 }
 
-func (a *App) processApprovedMatch(tx *gorm.DB, match *db.Match) error {
+// errMatchSettled is returned when a match was approved, expired or disputed by
+// someone else between being read and being settled.
+var errMatchSettled = errors.New("match has already been settled")
+
+// processApprovedMatch marks the match approved and completes whatever it was
+// the result of. The match must still be in fromState, so that two approvals of
+// the same match can't both go through.
+func (a *App) processApprovedMatch(tx *gorm.DB, match *db.Match, fromState string) error {
 	err := tx.Preload("Office").Find(&match, "id = ?", match.ID).Error
 	if err != nil {
 		return err
 	}
 
-	err = tx.Model(&db.Match{}).Where("id = ?", match.ID).Update("State", db.MatchStateApproved).Error
-	if err != nil {
+	result := tx.Model(&db.Match{}).Where("id = ? AND state = ?", match.ID, fromState).Update("State", db.MatchStateApproved)
+	if result.Error != nil {
 		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errMatchSettled
+	}
+
+	err = a.completeTournamentFixture(tx, match)
+	if err != nil {
 		return err
 	}
 
-	a.gp.InvalidateGameCache(match.OfficeID)
-	return nil
+	err = a.completeLadderChallenge(tx, match)
+	if err != nil {
+		return err
+	}
+
+	// Played in a season that has since ended, so its final standings are missing it
+	return clearSeasonStandings(tx, match)
 }
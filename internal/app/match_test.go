@@ -0,0 +1,53 @@
+package app
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParsePlayedAt(t *testing.T) {
+	now := time.Now().Truncate(time.Minute)
+
+	// The time as a browser with the given offset would send it
+	wallClock := func(at time.Time, tzOffset int) string {
+		return at.In(time.FixedZone("", -tzOffset*60)).Format(playedAtLayout)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		tzOffset string
+		want     time.Time
+		wantErr  bool
+	}{
+		{name: "just played", input: " ", tzOffset: "0", want: time.Time{}},
+		{name: "UTC", input: wallClock(now.Add(-time.Hour), 0), tzOffset: "0", want: now.Add(-time.Hour)},
+		{name: "west of UTC", input: wallClock(now.Add(-time.Hour), 300), tzOffset: "300", want: now.Add(-time.Hour)},
+		{name: "east of UTC", input: wallClock(now.Add(-time.Hour), -330), tzOffset: "-330", want: now.Add(-time.Hour)},
+		{name: "days ago", input: wallClock(now.AddDate(0, 0, -6), 60), tzOffset: "60", want: now.AddDate(0, 0, -6)},
+		{name: "future in the browser's timezone", input: wallClock(now.Add(time.Hour), -60), tzOffset: "-60", wantErr: true},
+		{name: "too long ago", input: wallClock(now.AddDate(0, 0, -8), 0), tzOffset: "0", wantErr: true},
+		{name: "no timezone", input: wallClock(now.Add(-time.Hour), 0), tzOffset: "", wantErr: true},
+		{name: "impossible timezone", input: wallClock(now.Add(-time.Hour), 0), tzOffset: strconv.Itoa(15 * 60), wantErr: true},
+		{name: "not a time", input: "yesterday", tzOffset: "0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePlayedAt(tt.input, tt.tzOffset)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePlayedAt() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePlayedAt() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParsePlayedAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
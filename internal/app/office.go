@@ -2,6 +2,7 @@ package app
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/RowMur/office-table-tennis/internal/db"
 	"gorm.io/gorm"
@@ -15,11 +16,18 @@ func (a *App) GetOfficeByCode(code string) (*db.Office, error) {
 			return db.Order("LOWER(username)")
 		}).
 		Preload("Matches", func(db *gorm.DB) *gorm.DB {
-			return db.Where("state != ?", "pending").Order("created_at DESC")
+			return db.Where("state = ?", "approved").Order("played_at DESC, id DESC")
 		}).
 		Preload("Matches.Participants.User").
 		Preload("Matches.Creator").
 		Preload("Matches.Approvals").
+		Preload("Matches.Scores", orderScores).
+		Preload("Seasons", func(db *gorm.DB) *gorm.DB {
+			return db.Order("start_date DESC")
+		}).
+		Preload("Games", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
 		Preload(clause.Associations).
 		First(office).Error
 	if err != nil {
@@ -77,3 +85,65 @@ func (a *App) CreateOffice(admin *db.User, name string) (*db.Office, error) {
 	db.InvalidateGetUserByIdCache(admin.ID)
 	return office, nil
 }
+
+// CreateGame adds another game to the office, starting with the default rules.
+func (a *App) CreateGame(office *db.Office, name string) (error, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("Game name is required"), nil
+	}
+	for _, game := range office.Games {
+		if strings.EqualFold(game.Name, name) {
+			return errors.New("The office already has this game"), nil
+		}
+	}
+
+	game := db.Game{
+		OfficeID: office.ID,
+		Name:     name,
+	}
+	err := a.db.C.Create(&game).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (a *App) UpdateGameSettings(game *db.Game, ratingSystem string, gameTarget int, winByTwo bool, settings db.RatingSettings) error {
+	err := a.db.C.Model(game).Updates(map[string]interface{}{
+		"rating_system":          ratingSystem,
+		"game_target":            gameTarget,
+		"win_by_two":             winByTwo,
+		"starting_points":        settings.StartingPoints,
+		"k_factor":               settings.KFactor,
+		"points_floor":           settings.PointsFloor,
+		"provisional_matches":    settings.ProvisionalMatches,
+		"provisional_multiplier": settings.ProvisionalMultiplier,
+		"handicap_multiplier":    settings.HandicapMultiplier,
+		"inactivity_weeks":       settings.InactivityWeeks,
+		"margin_of_victory":      settings.MarginOfVictory,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	// The whole history needs replaying under the new rules
+	a.gp.InvalidateGameCache(game.ID)
+	return nil
+}
+
+func (a *App) UpdateLadderSettings(game *db.Game, settings db.LadderSettings) error {
+	return a.db.C.Model(game).Updates(map[string]interface{}{
+		"ladder_enabled":        settings.Enabled,
+		"ladder_reach":          settings.Reach,
+		"ladder_challenge_days": settings.ChallengeDays,
+	}).Error
+}
+
+func (a *App) UpdatePendingMatchSettings(office *db.Office, settings db.PendingMatchSettings) error {
+	return a.db.C.Model(office).Updates(map[string]interface{}{
+		"pending_match_days":         settings.Days,
+		"pending_match_auto_approve": settings.AutoApprove,
+	}).Error
+}
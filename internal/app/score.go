@@ -0,0 +1,121 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+// ParseMatchScores reads scores written like "11-7, 9-11, 11-5", with the
+// winners' score first, and checks they make a valid best of N match under the
+// game's rules. No scores is fine, the score is optional.
+func ParseMatchScores(input string, bestOf int, game db.Game) ([]db.GameScore, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return []db.GameScore{}, nil
+	}
+
+	scores := []db.GameScore{}
+	for i, part := range strings.Split(input, ",") {
+		points := strings.Split(strings.TrimSpace(part), "-")
+		if len(points) != 2 {
+			return nil, fmt.Errorf("Game %d should look like 11-7", i+1)
+		}
+
+		winnersScore, err := strconv.Atoi(strings.TrimSpace(points[0]))
+		if err != nil {
+			return nil, fmt.Errorf("Game %d should look like 11-7", i+1)
+		}
+		losersScore, err := strconv.Atoi(strings.TrimSpace(points[1]))
+		if err != nil {
+			return nil, fmt.Errorf("Game %d should look like 11-7", i+1)
+		}
+
+		scores = append(scores, db.GameScore{
+			Number:       i + 1,
+			WinnersScore: winnersScore,
+			LosersScore:  losersScore,
+		})
+	}
+
+	err := ValidateMatchScores(scores, bestOf, game)
+	if err != nil {
+		return nil, err
+	}
+
+	return scores, nil
+}
+
+func ValidateMatchScores(scores []db.GameScore, bestOf int, game db.Game) error {
+	if bestOf < 1 || bestOf%2 == 0 {
+		return errors.New("Matches must be best of an odd number of games")
+	}
+	if len(scores) > bestOf {
+		return fmt.Errorf("A best of %d can't have %d games", bestOf, len(scores))
+	}
+
+	for _, score := range scores {
+		err := validateGameScore(score, game)
+		if err != nil {
+			return err
+		}
+	}
+
+	gamesToWin := bestOf/2 + 1
+	winnersGames := 0
+	losersGames := 0
+	for i, score := range scores {
+		if winnersGames == gamesToWin || losersGames == gamesToWin {
+			return fmt.Errorf("The match was already over before game %d", i+1)
+		}
+
+		if score.WinnersScore > score.LosersScore {
+			winnersGames++
+		} else {
+			losersGames++
+		}
+	}
+
+	if winnersGames != gamesToWin {
+		return fmt.Errorf("The winners need to win %d games in a best of %d", gamesToWin, bestOf)
+	}
+
+	return nil
+}
+
+func validateGameScore(score db.GameScore, game db.Game) error {
+	high := max(score.WinnersScore, score.LosersScore)
+	low := min(score.WinnersScore, score.LosersScore)
+	gameTarget := game.GameTarget
+
+	if low < 0 {
+		return fmt.Errorf("Game %d has a negative score", score.Number)
+	}
+	if high == low {
+		return fmt.Errorf("Game %d can't be a draw", score.Number)
+	}
+	if gameTarget == 0 {
+		// Anything goes without a target, as long as someone won
+		return nil
+	}
+	if !game.WinByTwo {
+		if high != gameTarget {
+			return fmt.Errorf("Game %d is won by the first to %d points", score.Number, gameTarget)
+		}
+		return nil
+	}
+	if high < gameTarget {
+		return fmt.Errorf("Game %d needs to reach %d points", score.Number, gameTarget)
+	}
+	if high-low < 2 {
+		return fmt.Errorf("Game %d must be won by 2 points", score.Number)
+	}
+	if high > gameTarget && high-low != 2 {
+		return fmt.Errorf("Game %d went past %d so must be won by exactly 2 points", score.Number, gameTarget)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+var (
+	tableTennis = db.Game{GameTarget: 11, WinByTwo: true}
+	firstTo21   = db.Game{GameTarget: 21}
+	pool        = db.Game{}
+)
+
+func TestParseMatchScores(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		bestOf  int
+		game    db.Game
+		want    [][2]int
+		wantErr bool
+	}{
+		{name: "no scores", input: "  ", bestOf: 3, game: tableTennis, want: [][2]int{}},
+		{name: "straight games", input: "11-7, 11-5", bestOf: 3, game: tableTennis, want: [][2]int{{11, 7}, {11, 5}}},
+		{name: "deciding game", input: "11-7,9-11, 11-5", bestOf: 3, game: tableTennis, want: [][2]int{{11, 7}, {9, 11}, {11, 5}}},
+		{name: "deuce", input: "13-11, 11-9", bestOf: 3, game: tableTennis, want: [][2]int{{13, 11}, {11, 9}}},
+		{name: "best of one", input: "11-3", bestOf: 1, game: tableTennis, want: [][2]int{{11, 3}}},
+		{name: "no target", input: "8-0", bestOf: 1, game: pool, want: [][2]int{{8, 0}}},
+		{name: "not a score", input: "11:7", bestOf: 1, game: tableTennis, wantErr: true},
+		{name: "not a number", input: "eleven-7", bestOf: 1, game: tableTennis, wantErr: true},
+		{name: "even best of", input: "11-7, 11-5", bestOf: 2, game: tableTennis, wantErr: true},
+		{name: "too many games", input: "11-7, 5-11, 11-5, 11-9", bestOf: 3, game: tableTennis, wantErr: true},
+		{name: "over already", input: "11-7, 11-5, 11-9", bestOf: 3, game: tableTennis, wantErr: true},
+		{name: "winners didn't win", input: "11-7, 5-11", bestOf: 3, game: tableTennis, wantErr: true},
+		{name: "losers won", input: "7-11, 5-11", bestOf: 3, game: tableTennis, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores, err := ParseMatchScores(tt.input, tt.bestOf, tt.game)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMatchScores() = %v, want an error", scores)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMatchScores() error = %v", err)
+			}
+
+			got := [][2]int{}
+			for i, score := range scores {
+				if score.Number != i+1 {
+					t.Errorf("game %d is numbered %d", i+1, score.Number)
+				}
+				got = append(got, [2]int{score.WinnersScore, score.LosersScore})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseMatchScores() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGameScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		score   [2]int
+		game    db.Game
+		wantErr bool
+	}{
+		{name: "to target", score: [2]int{11, 9}, game: tableTennis},
+		{name: "deuce", score: [2]int{12, 10}, game: tableTennis},
+		{name: "long deuce win by two", score: [2]int{20, 18}, game: tableTennis},
+		{name: "losers took it", score: [2]int{9, 11}, game: tableTennis},
+		{name: "short of target", score: [2]int{10, 8}, game: tableTennis, wantErr: true},
+		{name: "only by one", score: [2]int{11, 10}, game: tableTennis, wantErr: true},
+		{name: "too far past deuce", score: [2]int{15, 11}, game: tableTennis, wantErr: true},
+		{name: "draw", score: [2]int{11, 11}, game: tableTennis, wantErr: true},
+		{name: "negative", score: [2]int{11, -1}, game: tableTennis, wantErr: true},
+		{name: "first to", score: [2]int{21, 20}, game: firstTo21},
+		{name: "past first to", score: [2]int{22, 20}, game: firstTo21, wantErr: true},
+		{name: "no target", score: [2]int{3, 1}, game: pool},
+		{name: "no target draw", score: [2]int{1, 1}, game: pool, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateGameScore(db.GameScore{Number: 1, WinnersScore: tt.score[0], LosersScore: tt.score[1]}, tt.game)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateGameScore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"github.com/RowMur/office-table-tennis/internal/gameprocessor"
+	"gorm.io/gorm"
+)
+
+func (a *App) GetSeasonById(officeId uint, id string) (*db.Season, error) {
+	season := &db.Season{}
+	err := a.db.C.Where("office_id = ?", officeId).First(season, "id = ?", id).Error
+	if err != nil {
+		if db.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return season, nil
+}
+
+func (a *App) CreateSeason(office *db.Office, name string, startDate, endDate time.Time) (error, error) {
+	if name == "" {
+		return errors.New("Season name is required"), nil
+	}
+	if !endDate.After(startDate) {
+		return errors.New("Season must end after it starts"), nil
+	}
+
+	var overlapping int64
+	err := a.db.C.Model(&db.Season{}).
+		Where("office_id = ? AND start_date < ? AND end_date > ?", office.ID, endDate, startDate).
+		Count(&overlapping).Error
+	if err != nil {
+		return nil, err
+	}
+	if overlapping > 0 {
+		return errors.New("Seasons can't overlap"), nil
+	}
+
+	season := &db.Season{
+		OfficeID:  office.ID,
+		Name:      name,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+	err = a.db.C.Create(season).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// SeasonRankings returns a game's leaderboard for a season. Once a season has
+// ended its final standings are archived, and from then on are read back rather
+// than recalculated, until a match played during it is approved or corrected.
+func (a *App) SeasonRankings(gameId uint, season *db.Season) ([]gameprocessor.Player, error) {
+	if !season.HasEnded() {
+		processedGame, err := a.gp.ProcessSeason(gameId, *season)
+		if err != nil {
+			return nil, err
+		}
+
+		return processedGame.RankedPlayers(), nil
+	}
+
+	standings := []db.SeasonStanding{}
+	err := a.db.C.Where("season_id = ? AND game_id = ?", season.ID, gameId).
+		Preload("User").
+		Order("rank").
+		Find(&standings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(standings) == 0 {
+		standings, err = a.archiveSeasonStandings(gameId, season)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	players := []gameprocessor.Player{}
+	for _, standing := range standings {
+		players = append(players, gameprocessor.Player{
+			User:      standing.User,
+			IsActive:  true,
+			Points:    standing.Points,
+			WinCount:  standing.WinCount,
+			LossCount: standing.LossCount,
+		})
+	}
+
+	return players, nil
+}
+
+// clearSeasonStandings throws away the archived final standings of the season
+// the match was played in, so they're archived again with the match the next
+// time they're looked at.
+func clearSeasonStandings(tx *gorm.DB, match *db.Match) error {
+	seasons := tx.Model(&db.Season{}).Select("id").
+		Where("office_id = ? AND start_date <= ? AND end_date > ?", match.OfficeID, match.PlayedAt, match.PlayedAt)
+	return tx.Where("game_id = ? AND season_id IN (?)", match.GameID, seasons).Delete(&db.SeasonStanding{}).Error
+}
+
+func (a *App) archiveSeasonStandings(gameId uint, season *db.Season) ([]db.SeasonStanding, error) {
+	processedGame, err := a.gp.ProcessSeason(gameId, *season)
+	if err != nil {
+		return nil, err
+	}
+
+	standings := []db.SeasonStanding{}
+	for i, player := range processedGame.RankedPlayers() {
+		standings = append(standings, db.SeasonStanding{
+			SeasonID:  season.ID,
+			GameID:    gameId,
+			UserID:    player.User.ID,
+			User:      player.User,
+			Rank:      i + 1,
+			Points:    player.Points,
+			WinCount:  player.WinCount,
+			LossCount: player.LossCount,
+		})
+	}
+
+	if len(standings) == 0 {
+		return standings, nil
+	}
+
+	err = a.db.C.Omit("User").Create(&standings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return standings, nil
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"gorm.io/gorm"
+)
+
+// Matches in a session are dated this far apart, so they're rated in the order
+// they were entered
+const sessionMatchSpacing = time.Minute
+
+func (a *App) GetMatchSessionById(officeId uint, id string) (*db.MatchSession, error) {
+	session := &db.MatchSession{}
+	err := a.db.C.Where("office_id = ?", officeId).
+		Preload("Office").
+		Preload("Game").
+		Preload("Creator").
+		Preload("Matches", func(db *gorm.DB) *gorm.DB {
+			return db.Order("played_at, id")
+		}).
+		Preload("Matches.Participants.User").
+		Preload("Matches.Creator").
+		Preload("Matches.Approvals").
+		Preload("Matches.Scores", orderScores).
+		First(session, "id = ?", id).Error
+	if err != nil {
+		if db.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// LogMatchSession logs a run of matches in one go, in the order they were
+// played. Either every match is logged or none are. The last match is dated
+// playedAt, or now if it's zero, and the ones before it a little earlier.
+func (a *App) LogMatchSession(creator *db.User, office *db.Office, game *db.Game, playedAt time.Time, matches []MatchDetails) (*db.MatchSession, error) {
+	if len(matches) == 0 {
+		return nil, errors.New("Enter at least one match")
+	}
+	if playedAt.IsZero() {
+		playedAt = time.Now()
+	}
+
+	tx := a.db.C.Begin()
+
+	session := db.MatchSession{
+		OfficeID:  office.ID,
+		GameID:    game.ID,
+		CreatorID: creator.ID,
+	}
+	err := tx.Create(&session).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	for i, details := range matches {
+		details.GameID = game.ID
+		details.PlayedAt = playedAt.Add(-time.Duration(len(matches)-1-i) * sessionMatchSpacing)
+
+		match, err := logMatch(tx, creator, office, details, &session.ID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		session.Matches = append(session.Matches, *match)
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// ApproveMatchSession approves every pending match of the session that is
+// waiting on the user, either as one of its players or as the office admin.
+// Either every approval goes through or none do. The matches that end up
+// approved are rated in the order they were played.
+func (a *App) ApproveMatchSession(user *db.User, session *db.MatchSession) (error, error) {
+	matches := []*db.Match{}
+	for i := range session.Matches {
+		match := &session.Matches[i]
+		if match.State != db.MatchStatePending || match.IsApprovedByUser(user.ID) {
+			continue
+		}
+		if !match.HasParticipant(user.ID) && user.ID != session.Office.AdminRefer {
+			continue
+		}
+
+		matches = append(matches, match)
+	}
+
+	if len(matches) == 0 {
+		return errors.New("Nothing in this session is waiting on you"), nil
+	}
+
+	tx := a.db.C.Begin()
+
+	approved := []*db.Match{}
+	for _, match := range matches {
+		approval := db.MatchApproval{
+			MatchID: match.ID,
+			UserID:  user.ID,
+		}
+		err := tx.Create(&approval).Error
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		isApproved, err := a.IsMatchApproved(tx, match)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if !isApproved {
+			continue
+		}
+
+		err = a.processApprovedMatch(tx, match, db.MatchStatePending)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		approved = append(approved, match)
+	}
+
+	err := tx.Commit().Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, match := range approved {
+		a.applyApprovedMatch(match)
+	}
+
+	return nil, nil
+}
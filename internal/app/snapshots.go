@@ -0,0 +1,57 @@
+package app
+
+import (
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"github.com/RowMur/office-table-tennis/internal/gameprocessor"
+)
+
+// GetRatingSnapshots returns the game's current rating snapshots in the order
+// the matches were played.
+func (a *App) GetRatingSnapshots(gameId uint) ([]db.RatingSnapshot, error) {
+	snapshots := []db.RatingSnapshot{}
+	err := a.db.C.Joins("Match").
+		Preload("User").
+		Where("rating_snapshots.game_id = ? AND rating_snapshots.superseded_at IS NULL", gameId).
+		Order(`"Match".played_at, rating_snapshots.match_id, rating_snapshots.user_id`).
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshots, nil
+}
+
+// GetRatingHistory is the player's all time rating history in the game, read
+// from the rating snapshots.
+func (a *App) GetRatingHistory(gameId, userId uint) ([]gameprocessor.RatingPoint, error) {
+	snapshots := []db.RatingSnapshot{}
+	err := a.db.C.Joins("Match").
+		Where("rating_snapshots.game_id = ? AND rating_snapshots.user_id = ? AND rating_snapshots.superseded_at IS NULL", gameId, userId).
+		Order(`"Match".played_at, rating_snapshots.match_id`).
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return gameprocessor.SnapshotRatingHistory(snapshots), nil
+}
+
+// GetMatchPoints is the points won and lost in the matches, read from the
+// rating snapshots.
+func (a *App) GetMatchPoints(matches []db.Match) (gameprocessor.MatchPoints, error) {
+	matchIds := []uint{}
+	for _, match := range matches {
+		matchIds = append(matchIds, match.ID)
+	}
+
+	snapshots := []db.RatingSnapshot{}
+	if len(matchIds) > 0 {
+		err := a.db.C.Where("match_id IN ? AND superseded_at IS NULL", matchIds).
+			Find(&snapshots).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return gameprocessor.NewSnapshotPoints(matches, snapshots), nil
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"slices"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// swissFirstRound pairs the top half of the seeds against the bottom half, with
+// the lowest seed sitting out if there's an odd number.
+func swissFirstRound(tournamentId uint, seeded []db.User) []db.TournamentFixture {
+	playerIds := []uint{}
+	for _, player := range seeded {
+		playerIds = append(playerIds, player.ID)
+	}
+
+	var byeId *uint
+	if len(playerIds)%2 == 1 {
+		byeId = &playerIds[len(playerIds)-1]
+		playerIds = playerIds[:len(playerIds)-1]
+	}
+
+	half := len(playerIds) / 2
+	pairs := [][2]uint{}
+	for i := 0; i < half; i++ {
+		pairs = append(pairs, [2]uint{playerIds[i], playerIds[i+half]})
+	}
+
+	return swissFixtures(tournamentId, 1, pairs, byeId)
+}
+
+// swissFixtures lays out a round, with the bye, if there is one, at the bottom.
+// The bye counts as a win straight away.
+func swissFixtures(tournamentId uint, round int, pairs [][2]uint, byeId *uint) []db.TournamentFixture {
+	fixtures := []db.TournamentFixture{}
+	for i, pair := range pairs {
+		fixtures = append(fixtures, db.TournamentFixture{
+			TournamentID: tournamentId,
+			Round:        round,
+			Position:     i,
+			Player1ID:    &pair[0],
+			Player2ID:    &pair[1],
+		})
+	}
+
+	if byeId != nil {
+		fixtures = append(fixtures, db.TournamentFixture{
+			TournamentID: tournamentId,
+			Round:        round,
+			Position:     len(pairs),
+			Player1ID:    byeId,
+			WinnerID:     byeId,
+		})
+	}
+	return fixtures
+}
+
+// startNextSwissRound pairs up the next round of the Swiss tournament the match
+// was part of, once every match in the current round has been approved. It's
+// done after the match is processed so the pairings can see it.
+func (a *App) startNextSwissRound(match *db.Match) error {
+	fixture := db.TournamentFixture{}
+	err := a.db.C.Where("match_id = ?", match.ID).Limit(1).Find(&fixture).Error
+	if err != nil {
+		return err
+	}
+	if fixture.ID == 0 {
+		return nil
+	}
+
+	tournament := db.Tournament{}
+	err = a.db.C.Preload("Entrants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("seed")
+	}).
+		Preload("Entrants.User").
+		Preload("Fixtures").
+		Preload("Fixtures.Match.Scores").
+		First(&tournament, fixture.TournamentID).Error
+	if err != nil {
+		return err
+	}
+	if tournament.Format != db.TournamentFormatSwiss || tournament.IsFinished() {
+		return nil
+	}
+
+	for _, other := range tournament.Fixtures {
+		if other.Round == fixture.Round && other.WinnerID == nil {
+			return nil
+		}
+		if other.Round > fixture.Round {
+			// Already paired
+			return nil
+		}
+	}
+
+	standings := tournament.Standings()
+	if fixture.Round >= tournament.Rounds {
+		return a.db.C.Model(&db.Tournament{}).Where("id = ? AND winner_id IS NULL", tournament.ID).
+			Update("winner_id", standings[0].User.ID).Error
+	}
+
+	processedGame, err := a.gp.Process(tournament.GameID)
+	if err != nil {
+		return err
+	}
+
+	tournamentMatches := []uint{}
+	hadBye := map[uint]bool{}
+	for _, other := range tournament.Fixtures {
+		if other.MatchID != nil {
+			tournamentMatches = append(tournamentMatches, *other.MatchID)
+		}
+		if other.IsBye() {
+			hadBye[*other.WinnerID] = true
+		}
+	}
+
+	// Only meetings in this tournament count as rematches
+	haveMet := func(player1Id, player2Id uint) bool {
+		for _, matchId := range processedGame.OpposingMatches(player1Id, player2Id) {
+			if slices.Contains(tournamentMatches, matchId) {
+				return true
+			}
+		}
+		return false
+	}
+
+	playerIds := []uint{}
+	for _, standing := range standings {
+		playerIds = append(playerIds, standing.User.ID)
+	}
+
+	var byeId *uint
+	if len(playerIds)%2 == 1 {
+		// The lowest placed player who hasn't already had one gets the bye
+		byeIndex := len(playerIds) - 1
+		for i := len(playerIds) - 1; i >= 0; i-- {
+			if !hadBye[playerIds[i]] {
+				byeIndex = i
+				break
+			}
+		}
+
+		byeId = &playerIds[byeIndex]
+		playerIds = slices.Delete(slices.Clone(playerIds), byeIndex, byeIndex+1)
+	}
+
+	pairs, ok := pairSwiss(playerIds, haveMet)
+	if !ok {
+		// Everyone has played everyone they could, so allow rematches
+		pairs, _ = pairSwiss(playerIds, func(uint, uint) bool { return false })
+	}
+
+	// The last two matches of the round approved at once both get this far, and
+	// come up with the same pairings, so only the first to save them counts
+	fixtures := swissFixtures(tournament.ID, fixture.Round+1, pairs, byeId)
+	return a.db.C.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tournament_id"}, {Name: "round"}, {Name: "position"}},
+		DoNothing: true,
+	}).Create(&fixtures).Error
+}
+
+// pairSwiss pairs the players, who are ordered by their standing, each with the
+// closest player below them they haven't met, backtracking when that leaves
+// someone without an opponent.
+func pairSwiss(playerIds []uint, haveMet func(player1Id, player2Id uint) bool) ([][2]uint, bool) {
+	if len(playerIds) == 0 {
+		return [][2]uint{}, true
+	}
+
+	first := playerIds[0]
+	for i := 1; i < len(playerIds); i++ {
+		if haveMet(first, playerIds[i]) {
+			continue
+		}
+
+		rest := slices.Delete(slices.Clone(playerIds[1:]), i-1, i)
+		pairs, ok := pairSwiss(rest, haveMet)
+		if ok {
+			return append([][2]uint{{first, playerIds[i]}}, pairs...), true
+		}
+	}
+
+	return nil, false
+}
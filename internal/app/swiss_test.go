@@ -0,0 +1,65 @@
+package app
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPairSwiss(t *testing.T) {
+	tests := []struct {
+		name    string
+		players []uint
+		met     [][2]uint
+		want    [][2]uint
+		wantOk  bool
+	}{
+		{
+			name:    "nobody",
+			players: []uint{},
+			want:    [][2]uint{},
+			wantOk:  true,
+		},
+		{
+			name:    "down the standings",
+			players: []uint{1, 2, 3, 4},
+			want:    [][2]uint{{1, 2}, {3, 4}},
+			wantOk:  true,
+		},
+		{
+			name:    "skips a rematch",
+			players: []uint{1, 2, 3, 4},
+			met:     [][2]uint{{1, 2}},
+			want:    [][2]uint{{1, 3}, {2, 4}},
+			wantOk:  true,
+		},
+		{
+			name:    "backtracks",
+			players: []uint{1, 2, 3, 4},
+			met:     [][2]uint{{1, 2}, {2, 4}},
+			want:    [][2]uint{{1, 4}, {2, 3}},
+			wantOk:  true,
+		},
+		{
+			name:    "everyone has met",
+			players: []uint{1, 2, 3, 4},
+			met:     [][2]uint{{1, 2}, {1, 3}, {1, 4}},
+			wantOk:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			haveMet := func(player1Id, player2Id uint) bool {
+				return slices.Contains(tt.met, [2]uint{player1Id, player2Id}) || slices.Contains(tt.met, [2]uint{player2Id, player1Id})
+			}
+
+			got, ok := pairSwiss(tt.players, haveMet)
+			if ok != tt.wantOk {
+				t.Fatalf("pairSwiss() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && !slices.Equal(got, tt.want) {
+				t.Errorf("pairSwiss() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,469 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"gorm.io/gorm"
+)
+
+const (
+	MinTournamentEntrants = 2
+	MaxLeagueLegs         = 4
+)
+
+type TournamentDetails struct {
+	Name    string
+	Format  string
+	Players []string
+	// Only used by leagues
+	Legs int
+	// Only used by Swiss tournaments, 0 picks enough rounds for a clear winner
+	Rounds       int
+	PointsForWin int
+}
+
+func (a *App) GetTournaments(officeId uint) ([]db.Tournament, error) {
+	tournaments := []db.Tournament{}
+	err := a.db.C.Where("office_id = ?", officeId).
+		Order("created_at DESC").
+		Preload("Game").
+		Preload("Winner").
+		Find(&tournaments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return tournaments, nil
+}
+
+func (a *App) GetTournamentById(officeId uint, id string) (*db.Tournament, error) {
+	tournament := &db.Tournament{}
+	err := a.db.C.Where("office_id = ?", officeId).
+		Preload("Game").
+		Preload("Entrants", func(db *gorm.DB) *gorm.DB {
+			return db.Order("seed")
+		}).
+		Preload("Entrants.User").
+		Preload("Fixtures", func(db *gorm.DB) *gorm.DB {
+			return db.Order("round, position")
+		}).
+		Preload("Fixtures.Player1").
+		Preload("Fixtures.Player2").
+		Preload("Fixtures.Winner").
+		Preload("Fixtures.Match.Scores", orderScores).
+		Preload("Winner").
+		First(tournament, "id = ?", id).Error
+	if err != nil {
+		if db.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return tournament, nil
+}
+
+func (a *App) GetTournamentFixture(tournament *db.Tournament, id string) *db.TournamentFixture {
+	for _, fixture := range tournament.Fixtures {
+		if strconv.Itoa(int(fixture.ID)) == id {
+			return &fixture
+		}
+	}
+	return nil
+}
+
+// CreateTournament draws up the fixtures for the players, seeded by their
+// current rating in the game. In a knockout the best players meet as late as
+// possible.
+func (a *App) CreateTournament(office *db.Office, game *db.Game, details TournamentDetails) (*db.Tournament, error, error) {
+	if details.Name == "" {
+		return nil, errors.New("Tournament name is required"), nil
+	}
+
+	players := []db.User{}
+	for _, player := range office.Players {
+		if !player.NonPlayer && slices.Contains(details.Players, strconv.Itoa(int(player.ID))) {
+			players = append(players, player)
+		}
+	}
+	if len(players) < MinTournamentEntrants {
+		return nil, errors.New("Pick at least two players"), nil
+	}
+
+	switch details.Format {
+	case db.TournamentFormatKnockout:
+	case db.TournamentFormatLeague:
+		if details.Legs < 1 || details.Legs > MaxLeagueLegs {
+			return nil, fmt.Errorf("Players can play each other between 1 and %d times", MaxLeagueLegs), nil
+		}
+		if details.PointsForWin < 1 {
+			return nil, errors.New("A win must be worth at least 1 point"), nil
+		}
+	case db.TournamentFormatSwiss:
+		if details.Rounds == 0 {
+			details.Rounds = int(math.Ceil(math.Log2(float64(len(players)))))
+		}
+		if details.Rounds < 1 || details.Rounds >= len(players) {
+			return nil, fmt.Errorf("With %d players there can be between 1 and %d rounds", len(players), len(players)-1), nil
+		}
+		if details.PointsForWin < 1 {
+			return nil, errors.New("A win must be worth at least 1 point"), nil
+		}
+	default:
+		return nil, errors.New("Unknown tournament format"), nil
+	}
+
+	seeded, err := a.seedPlayers(game, players)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx := a.db.C.Begin()
+
+	tournament := db.Tournament{
+		OfficeID:     office.ID,
+		GameID:       game.ID,
+		Name:         details.Name,
+		Format:       details.Format,
+		Legs:         details.Legs,
+		Rounds:       details.Rounds,
+		PointsForWin: details.PointsForWin,
+	}
+	if err := tx.Create(&tournament).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	entrants := []db.TournamentEntrant{}
+	for i, player := range seeded {
+		entrants = append(entrants, db.TournamentEntrant{
+			TournamentID: tournament.ID,
+			UserID:       player.ID,
+			Seed:         i + 1,
+		})
+	}
+	if err := tx.Create(&entrants).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	fixtures := []db.TournamentFixture{}
+	switch tournament.Format {
+	case db.TournamentFormatKnockout:
+		fixtures = knockoutFixtures(tournament.ID, seeded)
+	case db.TournamentFormatLeague:
+		fixtures = leagueFixtures(tournament.ID, seeded, tournament.Legs)
+	case db.TournamentFormatSwiss:
+		fixtures = swissFirstRound(tournament.ID, seeded)
+	}
+	if err := tx.Create(&fixtures).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+
+	// Players with byes go straight through
+	for _, fixture := range fixtures {
+		if tournament.Format == db.TournamentFormatKnockout && fixture.Round == 1 && (fixture.Player1ID == nil) != (fixture.Player2ID == nil) {
+			winnerId := fixture.Player1ID
+			if winnerId == nil {
+				winnerId = fixture.Player2ID
+			}
+
+			if err := advanceKnockoutWinner(tx, &fixture, *winnerId); err != nil {
+				tx.Rollback()
+				return nil, nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, err
+	}
+
+	return &tournament, nil, nil
+}
+
+// seedPlayers orders the players by their current ranking, with anyone unranked
+// at the bottom.
+func (a *App) seedPlayers(game *db.Game, players []db.User) ([]db.User, error) {
+	processedGame, err := a.gp.Process(game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	rank := map[uint]int{}
+	for i, player := range processedGame.RankedPlayers() {
+		rank[player.User.ID] = i
+	}
+
+	seeded := slices.Clone(players)
+	sort.SliceStable(seeded, func(i, j int) bool {
+		rankI, rankedI := rank[seeded[i].ID]
+		rankJ, rankedJ := rank[seeded[j].ID]
+		if rankedI != rankedJ {
+			return rankedI
+		}
+		return rankI < rankJ
+	})
+
+	return seeded, nil
+}
+
+// knockoutFixtures lays out every round of the bracket. The bracket is padded to
+// a power of two with byes, which go to the top seeds.
+func knockoutFixtures(tournamentId uint, seeded []db.User) []db.TournamentFixture {
+	rounds := int(math.Ceil(math.Log2(float64(len(seeded)))))
+	size := 1 << rounds
+
+	fixtures := []db.TournamentFixture{}
+	order := bracketOrder(size)
+	for position := 0; position < size/2; position++ {
+		fixture := db.TournamentFixture{
+			TournamentID: tournamentId,
+			Round:        1,
+			Position:     position,
+		}
+		if seed := order[position*2]; seed <= len(seeded) {
+			fixture.Player1ID = &seeded[seed-1].ID
+		}
+		if seed := order[position*2+1]; seed <= len(seeded) {
+			fixture.Player2ID = &seeded[seed-1].ID
+		}
+		fixtures = append(fixtures, fixture)
+	}
+
+	for round := 2; round <= rounds; round++ {
+		for position := 0; position < size>>round; position++ {
+			fixtures = append(fixtures, db.TournamentFixture{
+				TournamentID: tournamentId,
+				Round:        round,
+				Position:     position,
+			})
+		}
+	}
+
+	return fixtures
+}
+
+// leagueFixtures pairs everyone with everyone else using the circle method, so
+// each round has everyone playing at most once. Each leg after the first
+// repeats the rounds with the sides swapped. With an odd number of players,
+// whoever would face the gap sits the round out.
+func leagueFixtures(tournamentId uint, players []db.User, legs int) []db.TournamentFixture {
+	circle := []*db.User{}
+	for i := range players {
+		circle = append(circle, &players[i])
+	}
+	if len(circle)%2 == 1 {
+		circle = append(circle, nil)
+	}
+
+	rounds := len(circle) - 1
+	fixtures := []db.TournamentFixture{}
+	for round := 0; round < rounds; round++ {
+		position := 0
+		for i := 0; i < len(circle)/2; i++ {
+			player1, player2 := circle[i], circle[len(circle)-1-i]
+			if player1 == nil || player2 == nil {
+				continue
+			}
+
+			for leg := 0; leg < legs; leg++ {
+				fixture := db.TournamentFixture{
+					TournamentID: tournamentId,
+					Round:        leg*rounds + round + 1,
+					Position:     position,
+					Player1ID:    &player1.ID,
+					Player2ID:    &player2.ID,
+				}
+				if leg%2 == 1 {
+					fixture.Player1ID, fixture.Player2ID = fixture.Player2ID, fixture.Player1ID
+				}
+				fixtures = append(fixtures, fixture)
+			}
+			position++
+		}
+
+		// Keep the first player where they are and rotate everyone else
+		circle = append([]*db.User{circle[0], circle[len(circle)-1]}, circle[1:len(circle)-1]...)
+	}
+
+	sort.SliceStable(fixtures, func(i, j int) bool {
+		return fixtures[i].Round < fixtures[j].Round
+	})
+	return fixtures
+}
+
+// bracketOrder is the order seeds are placed down the bracket so that 1 and 2
+// can only meet in the final, 1 to 4 in the semi finals and so on.
+func bracketOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := []int{}
+		for _, seed := range order {
+			next = append(next, seed, 2*len(order)+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+func (a *App) LogTournamentMatch(user *db.User, office *db.Office, tournament *db.Tournament, fixture *db.TournamentFixture, winnerId string, bestOf int, scores []db.GameScore) (*db.Match, error) {
+	if !fixture.Involves(user.ID) && user.ID != office.AdminRefer {
+		return nil, errors.New("Only the players in the match or the office admin can log its result")
+	}
+	if !fixture.IsReady() {
+		return nil, errors.New("This match can't be played yet")
+	}
+	if fixture.MatchID != nil {
+		return nil, errors.New("This match has already been logged")
+	}
+
+	player1Id := strconv.Itoa(int(*fixture.Player1ID))
+	player2Id := strconv.Itoa(int(*fixture.Player2ID))
+	details := MatchDetails{
+		GameID:  tournament.GameID,
+		Note:    tournament.Name,
+		Winners: []string{player1Id},
+		Losers:  []string{player2Id},
+		BestOf:  bestOf,
+		Scores:  scores,
+	}
+	switch winnerId {
+	case player1Id:
+	case player2Id:
+		details.Winners, details.Losers = details.Losers, details.Winners
+	default:
+		return nil, errors.New("The winner must be one of the players in the match")
+	}
+
+	tx := a.db.C.Begin()
+
+	match, err := logMatch(tx, user, office, details, nil)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	result := tx.Model(&db.TournamentFixture{}).Where("id = ? AND match_id IS NULL", fixture.ID).Update("match_id", match.ID)
+	if result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		// The other player got there first
+		tx.Rollback()
+		return nil, errors.New("This match has already been logged")
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		return nil, err
+	}
+
+	fixture.MatchID = &match.ID
+	return match, nil
+}
+
+// completeTournamentFixture moves the tournament on when the match approved was
+// one of its fixtures.
+func (a *App) completeTournamentFixture(tx *gorm.DB, match *db.Match) error {
+	fixture := db.TournamentFixture{}
+	err := tx.Where("match_id = ?", match.ID).Limit(1).Find(&fixture).Error
+	if err != nil {
+		return err
+	}
+	if fixture.ID == 0 {
+		return nil
+	}
+
+	winner := db.MatchParticipant{}
+	err = tx.Where("match_id = ? AND result = ?", match.ID, db.MatchResultWin).First(&winner).Error
+	if err != nil {
+		return err
+	}
+
+	tournament := db.Tournament{}
+	err = tx.First(&tournament, fixture.TournamentID).Error
+	if err != nil {
+		return err
+	}
+
+	switch tournament.Format {
+	case db.TournamentFormatLeague:
+		return completeLeagueFixture(tx, &tournament, &fixture, winner.UserID)
+	case db.TournamentFormatSwiss:
+		// The next round is paired once the match has been processed
+		return tx.Model(&fixture).Update("winner_id", winner.UserID).Error
+	}
+
+	return advanceKnockoutWinner(tx, &fixture, winner.UserID)
+}
+
+// completeLeagueFixture records the winner of the fixture, and the winner of the
+// league once every fixture has been played.
+func completeLeagueFixture(tx *gorm.DB, tournament *db.Tournament, fixture *db.TournamentFixture, winnerId uint) error {
+	err := tx.Model(fixture).Update("winner_id", winnerId).Error
+	if err != nil {
+		return err
+	}
+
+	var remaining int64
+	err = tx.Model(&db.TournamentFixture{}).
+		Where("tournament_id = ? AND winner_id IS NULL", tournament.ID).
+		Count(&remaining).Error
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return nil
+	}
+
+	err = tx.Preload("Entrants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("seed")
+	}).
+		Preload("Entrants.User").
+		Preload("Fixtures").
+		Preload("Fixtures.Match.Scores").
+		First(tournament, tournament.ID).Error
+	if err != nil {
+		return err
+	}
+
+	standings := tournament.Standings()
+	return tx.Model(tournament).Update("winner_id", standings[0].User.ID).Error
+}
+
+// advanceKnockoutWinner records the winner of the fixture and puts them into
+// their next match, or crowns them if that was the final.
+func advanceKnockoutWinner(tx *gorm.DB, fixture *db.TournamentFixture, winnerId uint) error {
+	err := tx.Model(fixture).Update("winner_id", winnerId).Error
+	if err != nil {
+		return err
+	}
+
+	next := db.TournamentFixture{}
+	err = tx.Where("tournament_id = ? AND round = ? AND position = ?", fixture.TournamentID, fixture.Round+1, fixture.Position/2).
+		Limit(1).
+		Find(&next).Error
+	if err != nil {
+		return err
+	}
+
+	if next.ID == 0 {
+		return tx.Model(&db.Tournament{}).Where("id = ?", fixture.TournamentID).Update("winner_id", winnerId).Error
+	}
+
+	column := "player1_id"
+	if fixture.Position%2 == 1 {
+		column = "player2_id"
+	}
+	return tx.Model(&next).Update(column, winnerId).Error
+}
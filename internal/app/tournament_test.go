@@ -0,0 +1,172 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func testUsers(count int) []db.User {
+	users := []db.User{}
+	for i := range count {
+		user := db.User{}
+		user.ID = uint(i + 1)
+		users = append(users, user)
+	}
+	return users
+}
+
+func fixturePlayer(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+func TestBracketOrder(t *testing.T) {
+	tests := []struct {
+		size int
+		want []int
+	}{
+		{size: 1, want: []int{1}},
+		{size: 2, want: []int{1, 2}},
+		{size: 4, want: []int{1, 4, 2, 3}},
+		{size: 8, want: []int{1, 8, 4, 5, 2, 7, 3, 6}},
+	}
+
+	for _, tt := range tests {
+		if got := bracketOrder(tt.size); !slices.Equal(got, tt.want) {
+			t.Errorf("bracketOrder(%d) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestKnockoutFixtures(t *testing.T) {
+	tests := []struct {
+		name    string
+		players int
+		// Player IDs of each first round fixture, 0 for a bye
+		wantFirstRound [][2]uint
+		wantFixtures   int
+	}{
+		{
+			name:           "final only",
+			players:        2,
+			wantFirstRound: [][2]uint{{1, 2}},
+			wantFixtures:   1,
+		},
+		{
+			name:           "full bracket",
+			players:        4,
+			wantFirstRound: [][2]uint{{1, 4}, {2, 3}},
+			wantFixtures:   3,
+		},
+		{
+			name:           "byes to the top seeds",
+			players:        6,
+			wantFirstRound: [][2]uint{{1, 0}, {4, 5}, {2, 0}, {3, 6}},
+			wantFixtures:   7,
+		},
+		{
+			name:           "one bye",
+			players:        3,
+			wantFirstRound: [][2]uint{{1, 0}, {2, 3}},
+			wantFixtures:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixtures := knockoutFixtures(7, testUsers(tt.players))
+			if len(fixtures) != tt.wantFixtures {
+				t.Fatalf("got %d fixtures, want %d", len(fixtures), tt.wantFixtures)
+			}
+
+			firstRound := [][2]uint{}
+			for _, fixture := range fixtures {
+				if fixture.TournamentID != 7 {
+					t.Errorf("fixture is for tournament %d", fixture.TournamentID)
+				}
+				if fixture.Round != 1 {
+					if fixture.Player1ID != nil || fixture.Player2ID != nil {
+						t.Errorf("round %d fixture already has players", fixture.Round)
+					}
+					continue
+				}
+
+				if fixture.Position != len(firstRound) {
+					t.Errorf("first round fixture %d is at position %d", len(firstRound), fixture.Position)
+				}
+				firstRound = append(firstRound, [2]uint{fixturePlayer(fixture.Player1ID), fixturePlayer(fixture.Player2ID)})
+			}
+			if !slices.Equal(firstRound, tt.wantFirstRound) {
+				t.Errorf("first round is %v, want %v", firstRound, tt.wantFirstRound)
+			}
+		})
+	}
+}
+
+func TestLeagueFixtures(t *testing.T) {
+	tests := []struct {
+		name         string
+		players      int
+		legs         int
+		wantRounds   int
+		wantFixtures int
+	}{
+		{name: "two players", players: 2, legs: 1, wantRounds: 1, wantFixtures: 1},
+		{name: "even", players: 4, legs: 1, wantRounds: 3, wantFixtures: 6},
+		{name: "odd", players: 5, legs: 1, wantRounds: 5, wantFixtures: 10},
+		{name: "home and away", players: 4, legs: 2, wantRounds: 6, wantFixtures: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixtures := leagueFixtures(7, testUsers(tt.players), tt.legs)
+			if len(fixtures) != tt.wantFixtures {
+				t.Fatalf("got %d fixtures, want %d", len(fixtures), tt.wantFixtures)
+			}
+
+			// Each way round a pair played, and who played in each round
+			meetings := map[[2]uint]int{}
+			playing := map[int]map[uint]bool{}
+			for i, fixture := range fixtures {
+				if i > 0 && fixture.Round < fixtures[i-1].Round {
+					t.Errorf("fixture %d is in round %d, after round %d", i, fixture.Round, fixtures[i-1].Round)
+				}
+				if fixture.Round < 1 || fixture.Round > tt.wantRounds {
+					t.Errorf("fixture %d is in round %d of %d", i, fixture.Round, tt.wantRounds)
+				}
+
+				player1, player2 := *fixture.Player1ID, *fixture.Player2ID
+				if player1 == player2 {
+					t.Errorf("player %d plays themselves", player1)
+				}
+				meetings[[2]uint{player1, player2}]++
+
+				if playing[fixture.Round] == nil {
+					playing[fixture.Round] = map[uint]bool{}
+				}
+				for _, id := range []uint{player1, player2} {
+					if playing[fixture.Round][id] {
+						t.Errorf("player %d plays twice in round %d", id, fixture.Round)
+					}
+					playing[fixture.Round][id] = true
+				}
+			}
+
+			for player1 := uint(1); player1 <= uint(tt.players); player1++ {
+				for player2 := player1 + 1; player2 <= uint(tt.players); player2++ {
+					home, away := meetings[[2]uint{player1, player2}], meetings[[2]uint{player2, player1}]
+					if home+away != tt.legs {
+						t.Errorf("players %d and %d meet %d times, want %d", player1, player2, home+away, tt.legs)
+					}
+					if tt.legs == 2 && home != 1 {
+						t.Errorf("players %d and %d don't swap sides in the second leg", player1, player2)
+					}
+				}
+			}
+		})
+	}
+}
@@ -31,6 +31,16 @@ func Init() Database {
 		log.Fatalf("Error migrating models: %v", err)
 	}
 
+	err = migrateOfficeGames(db)
+	if err != nil {
+		log.Fatalf("Error migrating office games: %v", err)
+	}
+
+	err = migrateMatchPlayedAt(db)
+	if err != nil {
+		log.Fatalf("Error migrating match played at: %v", err)
+	}
+
 	databaseSingleton.C = db
 	return databaseSingleton
 }
@@ -38,3 +48,25 @@ func Init() Database {
 func IsRecordNotFoundError(err error) bool {
 	return errors.Is(err, gorm.ErrRecordNotFound)
 }
+
+// migrateOfficeGames gives offices from before there could be more than one game
+// a table tennis game, with the default settings they were always rated with,
+// and their matches.
+func migrateOfficeGames(db *gorm.DB) error {
+	err := db.Exec(`INSERT INTO games (created_at, updated_at, office_id, name)
+		SELECT NOW(), NOW(), offices.id, ? FROM offices
+		WHERE NOT EXISTS (SELECT 1 FROM games WHERE games.office_id = offices.id)`, DefaultGameName).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`UPDATE matches SET game_id = (
+			SELECT MIN(games.id) FROM games WHERE games.office_id = matches.office_id
+		) WHERE game_id IS NULL`).Error
+}
+
+// migrateMatchPlayedAt dates matches logged before they had a played at time to
+// when they were logged.
+func migrateMatchPlayedAt(db *gorm.DB) error {
+	return db.Exec("UPDATE matches SET played_at = created_at WHERE played_at IS NULL").Error
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,9 +13,42 @@ import (
 var Models = []interface{}{
 	&User{},
 	&Office{},
+	&Game{},
 	&Match{},
+	&MatchSession{},
 	&MatchApproval{},
+	&MatchCorrection{},
 	&MatchParticipant{},
+	&GameScore{},
+	&RatingSnapshot{},
+	&Season{},
+	&SeasonStanding{},
+	&Challenge{},
+	&Tournament{},
+	&TournamentEntrant{},
+	&TournamentFixture{},
+	&LadderPosition{},
+}
+
+const (
+	RatingSystemElo       = "elo"
+	RatingSystemGlicko    = "glicko2"
+	RatingSystemTrueSkill = "trueskill"
+)
+
+// RatingSettings are the rating parameters an office admin can tune. Not every
+// rating system makes use of all of them.
+type RatingSettings struct {
+	StartingPoints        int     `gorm:"default:400"`
+	KFactor               int     `gorm:"default:32"`
+	PointsFloor           int     `gorm:"default:200"`
+	ProvisionalMatches    int     `gorm:"default:20"`
+	ProvisionalMultiplier float64 `gorm:"default:2"`
+	HandicapMultiplier    float64 `gorm:"default:0.25"`
+	InactivityWeeks       int     `gorm:"default:8"`
+	// How much the score of the match, when one was recorded, scales the points,
+	// from 0 for not at all to 1 for fully
+	MarginOfVictory float64 `gorm:"default:0"`
 }
 
 type Office struct {
@@ -23,13 +58,65 @@ type Office struct {
 	AdminRefer uint
 	Admin      User   `gorm:"foreignKey:AdminRefer"`
 	Players    []User `gorm:"many2many:user_offices;"`
+	Games      []Game
 	Matches    []Match
+	Seasons    []Season
+	// What happens to matches nobody gets round to approving
+	PendingMatches PendingMatchSettings `gorm:"embedded;embeddedPrefix:pending_match_"`
+}
+
+type PendingMatchSettings struct {
+	// Days a match can wait for approval before it's settled, 0 to wait forever.
+	// Offices have to opt in.
+	Days int `gorm:"default:0"`
+	// Whether a match the creator's side approved goes through once the days
+	// are up. Otherwise it expires.
+	AutoApprove bool `gorm:"default:true"`
+}
+
+// DefaultGameName is the game every office starts out with.
+const DefaultGameName = "Table Tennis"
+
+// Game is one of the things played in an office, like table tennis or pool.
+// Each game has its own matches, rankings and rules.
+type Game struct {
+	gorm.Model
+	OfficeID       uint
+	Office         Office
+	Name           string
+	RatingSystem   string         `gorm:"default:'elo'"`
+	RatingSettings RatingSettings `gorm:"embedded"`
+	// Points needed to win a game, usually 11 or 21. 0 for games without a
+	// points target, like pool.
+	GameTarget int `gorm:"default:11"`
+	// Whether a game past its target must be won by 2 points, as in table tennis
+	WinByTwo bool           `gorm:"default:true"`
+	Ladder   LadderSettings `gorm:"embedded;embeddedPrefix:ladder_"`
+}
+
+type LadderSettings struct {
+	Enabled bool `gorm:"default:false"`
+	// How many places above themselves a player can challenge
+	Reach int `gorm:"default:3"`
+	// Days a ladder challenge has to be answered and played in
+	ChallengeDays int `gorm:"default:7"`
 }
 
 func (o *Office) Link() string {
 	return fmt.Sprintf("/offices/%s", o.Code)
 }
 
+// GameMatches are the office's matches that were played in the game.
+func (o *Office) GameMatches(gameId uint) []Match {
+	matches := []Match{}
+	for _, match := range o.Matches {
+		if match.GameID == gameId {
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
 func generateCode() string {
 	lengthOfCode := 6
 	chars := []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ")
@@ -61,6 +148,13 @@ func (o *Office) AfterCreate(tx *gorm.DB) (err error) {
 		}
 	}
 
+	if len(o.Games) == 0 {
+		err = tx.Create(&Game{OfficeID: o.ID, Name: DefaultGameName}).Error
+		if err != nil {
+			return
+		}
+	}
+
 	return
 }
 
@@ -96,12 +190,20 @@ func (mp *MatchParticipant) AfterCreate(tx *gorm.DB) (err error) {
 const (
 	MatchStatePending  = "pending"
 	MatchStateApproved = "approved"
+	// A participant disagrees with the result, the office admin settles it
+	MatchStateDisputed = "disputed"
+	// Thrown away by the office admin after it was approved
+	MatchStateVoided = "voided"
+	// Left pending past the office's approval window, and thrown away
+	MatchStateExpired = "expired"
 )
 
 type Match struct {
 	gorm.Model
 	OfficeID     uint
 	Office       Office
+	GameID       uint
+	Game         Game
 	CreatorID    uint
 	Creator      User
 	Participants []MatchParticipant
@@ -109,6 +211,29 @@ type Match struct {
 	Approvals    []MatchApproval
 	Note         string
 	IsHandicap   bool
+	// Number of games the match was played over, 0 if the score wasn't recorded
+	BestOf int
+	Scores []GameScore
+	// Why the match was disputed, and by who
+	DisputeReason string
+	DisputedByID  *uint
+	DisputedBy    *User
+	// When the office admin last changed the match after it was approved
+	CorrectedAt *time.Time
+	// When the players were told the match is about to be settled for them
+	ExpiryWarnedAt *time.Time
+	// When the match was actually played, which can be a while before it was
+	// logged. Matches are rated in this order.
+	PlayedAt time.Time `gorm:"index"`
+	// The session the match was logged as part of, if any
+	SessionID *uint
+}
+
+func (m *Match) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.PlayedAt.IsZero() {
+		m.PlayedAt = time.Now()
+	}
+	return
 }
 
 func (m *Match) BeforeDelete(tx *gorm.DB) (err error) {
@@ -123,9 +248,34 @@ func (m *Match) BeforeDelete(tx *gorm.DB) (err error) {
 	if err != nil {
 		return
 	}
+	err = tx.Where("match_id = ?", m.ID).Delete(&GameScore{}).Error
+	if err != nil {
+		return
+	}
+	err = tx.Model(&TournamentFixture{}).Where("match_id = ?", m.ID).Update("match_id", nil).Error
+	if err != nil {
+		return
+	}
+	// The challenge is back on if its result is thrown away
+	err = tx.Model(&Challenge{}).Where("match_id = ?", m.ID).Updates(map[string]interface{}{
+		"match_id": nil,
+		"state":    ChallengeStateAccepted,
+	}).Error
+	if err != nil {
+		return
+	}
 	return
 }
 
+func (m *Match) HasParticipant(userID uint) bool {
+	for _, participant := range m.Participants {
+		if participant.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *Match) IsApprovedByUser(userID uint) bool {
 	for _, approval := range m.Approvals {
 		if approval.UserID == userID {
@@ -176,6 +326,31 @@ func (m *Match) IsApproved() bool {
 	return (m.IsApprovedByWinners() && m.IsApprovedByLosers()) || m.IsAdminApproved()
 }
 
+// IsApprovedByCreatorSide is whether the side the match's creator played on has
+// approved it. A creator who didn't play has no side.
+func (m *Match) IsApprovedByCreatorSide() bool {
+	for _, participant := range m.Participants {
+		if participant.UserID != m.CreatorID {
+			continue
+		}
+		if participant.Result == MatchResultWin {
+			return m.IsApprovedByWinners()
+		}
+		return m.IsApprovedByLosers()
+	}
+	return false
+}
+
+// IsApprovedOnExpiry is whether the match goes through when it's settled for
+// being left pending too long, rather than expiring. The creator's side is
+// enough if the office auto-approves.
+func (m *Match) IsApprovedOnExpiry() bool {
+	if m.IsApproved() {
+		return true
+	}
+	return m.Office.PendingMatches.AutoApprove && m.IsApprovedByCreatorSide()
+}
+
 func (m *Match) Winners() []MatchParticipant {
 	var winners []MatchParticipant
 	for _, participant := range m.Participants {
@@ -196,6 +371,46 @@ func (m *Match) Losers() []MatchParticipant {
 	return losers
 }
 
+// ScoreSummary is the match's score game by game, from the winners' side.
+func (m *Match) ScoreSummary() string {
+	games := []string{}
+	for _, score := range m.Scores {
+		games = append(games, fmt.Sprintf("%d-%d", score.WinnersScore, score.LosersScore))
+	}
+	return strings.Join(games, ", ")
+}
+
+// PointDifferential is how many more points the winners scored than the losers
+// across every game.
+func (m *Match) PointDifferential() int {
+	differential := 0
+	for _, score := range m.Scores {
+		differential += score.WinnersScore - score.LosersScore
+	}
+	return differential
+}
+
+// GamesWon is how many games each side won.
+func (m *Match) GamesWon() (winners, losers int) {
+	for _, score := range m.Scores {
+		if score.WinnersScore > score.LosersScore {
+			winners++
+		} else {
+			losers++
+		}
+	}
+	return
+}
+
+// GameScore is the score of a single game within a match.
+type GameScore struct {
+	gorm.Model
+	MatchID      uint
+	Number       int
+	WinnersScore int
+	LosersScore  int
+}
+
 type MatchApproval struct {
 	gorm.Model
 	MatchID uint
@@ -203,3 +418,224 @@ type MatchApproval struct {
 	UserID  uint
 	User    User
 }
+
+// MatchSession is a run of matches logged together, like an afternoon of
+// doubles rotations, so they can be approved in one go.
+type MatchSession struct {
+	gorm.Model
+	OfficeID  uint
+	Office    Office
+	GameID    uint
+	Game      Game
+	CreatorID uint
+	Creator   User
+	Matches   []Match `gorm:"foreignKey:SessionID"`
+}
+
+const (
+	MatchCorrectionEdited = "edited"
+	MatchCorrectionVoided = "voided"
+)
+
+// MatchCorrection is the audit record of an office admin editing or voiding an
+// approved match. It keeps the result as it stood before the change.
+type MatchCorrection struct {
+	gorm.Model
+	MatchID         uint
+	Match           Match
+	AdminID         uint
+	Admin           User
+	Action          string
+	OriginalWinners string
+	OriginalLosers  string
+	OriginalScores  string
+	OriginalNote    string
+}
+
+type Season struct {
+	gorm.Model
+	OfficeID  uint
+	Office    Office
+	Name      string
+	StartDate time.Time
+	EndDate   time.Time
+	Standings []SeasonStanding
+}
+
+func (s *Season) HasEnded() bool {
+	return time.Now().After(s.EndDate)
+}
+
+func (s *Season) IsActive() bool {
+	now := time.Now()
+	return !now.Before(s.StartDate) && now.Before(s.EndDate)
+}
+
+func (s *Season) Contains(t time.Time) bool {
+	return !t.Before(s.StartDate) && t.Before(s.EndDate)
+}
+
+// SeasonStanding is a row of a season's final leaderboard, frozen once the season
+// has ended so it isn't affected by later changes to the office.
+type SeasonStanding struct {
+	gorm.Model
+	SeasonID  uint
+	GameID    uint
+	UserID    uint
+	User      User
+	Rank      int
+	Points    int
+	WinCount  int
+	LossCount int
+}
+
+// RatingSnapshot is a participant's rating either side of an approved match, as
+// calculated by the game processor. When a replay works out a different rating
+// the snapshot is superseded by a new one rather than overwritten, so there is
+// only ever one current snapshot for each participant of a match.
+type RatingSnapshot struct {
+	gorm.Model
+	OfficeID     uint
+	GameID       uint `gorm:"index"`
+	MatchID      uint `gorm:"uniqueIndex:idx_rating_snapshot_current,where:superseded_at IS NULL"`
+	Match        Match
+	UserID       uint `gorm:"uniqueIndex:idx_rating_snapshot_current,where:superseded_at IS NULL"`
+	User         User
+	PointsBefore int
+	PointsAfter  int
+	SupersededAt *time.Time
+}
+
+func (rs *RatingSnapshot) PointsApplied() int {
+	return rs.PointsAfter - rs.PointsBefore
+}
+
+const (
+	ChallengeStateOpen     = "open"
+	ChallengeStateAccepted = "accepted"
+	ChallengeStateDeclined = "declined"
+	ChallengeStatePlayed   = "played"
+	// A ladder challenge that went unanswered
+	ChallengeStateForfeited = "forfeited"
+	// A ladder challenge that was accepted but not played in time
+	ChallengeStateLapsed = "lapsed"
+)
+
+// Challenge is one player calling out another for a singles match. Once it has
+// been played it links to the logged match.
+type Challenge struct {
+	gorm.Model
+	OfficeID     uint
+	Office       Office
+	GameID       uint
+	Game         Game
+	ChallengerID uint
+	Challenger   User
+	OpponentID   uint
+	Opponent     User
+	State        string `gorm:"default:'open'"`
+	// Optional, the challenge lapses if it isn't played by then
+	Deadline *time.Time
+	Note     string
+	MatchID  *uint
+	Match    *Match
+	// Ladder challenges move players up the ladder
+	Ladder bool
+}
+
+func (c *Challenge) IsExpired() bool {
+	return c.Deadline != nil && time.Now().After(*c.Deadline)
+}
+
+// IsOutstanding is whether the challenge is still waiting on a response or to
+// be played.
+func (c *Challenge) IsOutstanding() bool {
+	return (c.State == ChallengeStateOpen || c.State == ChallengeStateAccepted) && !c.IsExpired()
+}
+
+func (c *Challenge) Involves(userID uint) bool {
+	return c.ChallengerID == userID || c.OpponentID == userID
+}
+
+const (
+	TournamentFormatKnockout = "knockout"
+	TournamentFormatLeague   = "league"
+	TournamentFormatSwiss    = "swiss"
+)
+
+type Tournament struct {
+	gorm.Model
+	OfficeID uint
+	Office   Office
+	GameID   uint
+	Game     Game
+	Name     string
+	Format   string `gorm:"default:'knockout'"`
+	// How many times everyone plays each other in a league
+	Legs int `gorm:"default:1"`
+	// How many rounds a Swiss tournament runs for
+	Rounds int
+	// League and Swiss points for each match won
+	PointsForWin int `gorm:"default:2"`
+	Entrants     []TournamentEntrant
+	Fixtures     []TournamentFixture
+	// Set once the tournament has been decided
+	WinnerID *uint
+	Winner   *User
+}
+
+func (t *Tournament) IsFinished() bool {
+	return t.WinnerID != nil
+}
+
+type TournamentEntrant struct {
+	gorm.Model
+	TournamentID uint
+	UserID       uint
+	User         User
+	// 1 is the top seed
+	Seed int
+}
+
+// TournamentFixture is a match to be played as part of a tournament. Players
+// are left empty until they are known, and a fixture with only one player is a
+// bye.
+type TournamentFixture struct {
+	gorm.Model
+	TournamentID uint `gorm:"uniqueIndex:idx_tournament_fixture_position"`
+	Tournament   Tournament
+	// Rounds start at 1 and positions at 0
+	Round     int `gorm:"uniqueIndex:idx_tournament_fixture_position"`
+	Position  int `gorm:"uniqueIndex:idx_tournament_fixture_position"`
+	Player1ID *uint
+	Player1   *User
+	Player2ID *uint
+	Player2   *User
+	MatchID   *uint
+	Match     *Match
+	WinnerID  *uint
+	Winner    *User
+}
+
+func (f *TournamentFixture) IsReady() bool {
+	return f.Player1ID != nil && f.Player2ID != nil && f.WinnerID == nil
+}
+
+// IsBye is whether the fixture only ever had one player.
+func (f *TournamentFixture) IsBye() bool {
+	return (f.Player1ID == nil) != (f.Player2ID == nil) && f.WinnerID != nil && f.MatchID == nil
+}
+
+func (f *TournamentFixture) Involves(userID uint) bool {
+	return (f.Player1ID != nil && *f.Player1ID == userID) || (f.Player2ID != nil && *f.Player2ID == userID)
+}
+
+// LadderPosition is a player's place on a game's ladder, 1 being the top.
+type LadderPosition struct {
+	gorm.Model
+	OfficeID uint
+	GameID   uint `gorm:"uniqueIndex:idx_ladder_position_game_user"`
+	UserID   uint `gorm:"uniqueIndex:idx_ladder_position_game_user"`
+	User     User
+	Position int
+}
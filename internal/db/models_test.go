@@ -0,0 +1,60 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChallengeIsOutstanding(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		state    string
+		deadline *time.Time
+		want     bool
+	}{
+		{name: "open", state: ChallengeStateOpen, want: true},
+		{name: "accepted before the deadline", state: ChallengeStateAccepted, deadline: &future, want: true},
+		{name: "accepted past the deadline", state: ChallengeStateAccepted, deadline: &past, want: false},
+		{name: "open past the deadline", state: ChallengeStateOpen, deadline: &past, want: false},
+		{name: "declined", state: ChallengeStateDeclined, want: false},
+		{name: "played", state: ChallengeStatePlayed, want: false},
+		{name: "forfeited", state: ChallengeStateForfeited, want: false},
+		{name: "lapsed", state: ChallengeStateLapsed, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenge := Challenge{State: tt.state, Deadline: tt.deadline}
+			if got := challenge.IsOutstanding(); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeasonContains(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	season := Season{StartDate: start, EndDate: start.AddDate(0, 3, 0)}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{name: "before it starts", at: start.Add(-time.Second), want: false},
+		{name: "as it starts", at: start, want: true},
+		{name: "during", at: start.AddDate(0, 1, 0), want: true},
+		{name: "as it ends", at: season.EndDate, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := season.Contains(tt.at); got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"sort"
+)
+
+// TournamentStanding is a row of a tournament's table.
+type TournamentStanding struct {
+	User      User
+	Played    int
+	Won       int
+	Lost      int
+	GamesWon  int
+	GamesLost int
+	Points    int
+	// Sum of the points of everyone the player has played, for Swiss tiebreaks
+	Buchholz int
+}
+
+// Standings is the table from the fixtures decided so far. Ties on points are
+// broken by games won and then by the results between the tied players, with
+// Swiss tournaments looking at Buchholz first. It needs the entrants and
+// fixtures, with their matches and scores, to be loaded.
+func (t *Tournament) Standings() []TournamentStanding {
+	standings := map[uint]*TournamentStanding{}
+	for _, entrant := range t.Entrants {
+		standings[entrant.UserID] = &TournamentStanding{User: entrant.User}
+	}
+
+	// Wins between each pair of players, for breaking ties
+	wins := map[uint]map[uint]int{}
+	opponents := map[uint][]uint{}
+
+	for _, fixture := range t.Fixtures {
+		if fixture.WinnerID == nil {
+			continue
+		}
+
+		if fixture.IsBye() {
+			// A Swiss bye is worth a win, knockout byes don't make it into a table
+			if standing := standings[*fixture.WinnerID]; standing != nil {
+				standing.Won++
+				standing.Points += t.PointsForWin
+			}
+			continue
+		}
+
+		winnerId := *fixture.WinnerID
+		loserId := *fixture.Player1ID
+		if loserId == winnerId {
+			loserId = *fixture.Player2ID
+		}
+
+		winnerGames, loserGames := 1, 0
+		if fixture.Match != nil && len(fixture.Match.Scores) > 0 {
+			winnerGames, loserGames = fixture.Match.GamesWon()
+		}
+
+		winner, loser := standings[winnerId], standings[loserId]
+		if winner == nil || loser == nil {
+			continue
+		}
+
+		winner.Played++
+		winner.Won++
+		winner.GamesWon += winnerGames
+		winner.GamesLost += loserGames
+		winner.Points += t.PointsForWin
+
+		loser.Played++
+		loser.Lost++
+		loser.GamesWon += loserGames
+		loser.GamesLost += winnerGames
+
+		if wins[winnerId] == nil {
+			wins[winnerId] = map[uint]int{}
+		}
+		wins[winnerId][loserId]++
+
+		opponents[winnerId] = append(opponents[winnerId], loserId)
+		opponents[loserId] = append(opponents[loserId], winnerId)
+	}
+
+	for userId, standing := range standings {
+		for _, opponentId := range opponents[userId] {
+			standing.Buchholz += standings[opponentId].Points
+		}
+	}
+
+	table := []TournamentStanding{}
+	for _, entrant := range t.Entrants {
+		table = append(table, *standings[entrant.UserID])
+	}
+
+	isSwiss := t.Format == TournamentFormatSwiss
+	tied := func(a, b TournamentStanding) bool {
+		return a.Points == b.Points && a.GamesWon == b.GamesWon && (!isSwiss || a.Buchholz == b.Buchholz)
+	}
+
+	sort.SliceStable(table, func(i, j int) bool {
+		if table[i].Points != table[j].Points {
+			return table[i].Points > table[j].Points
+		}
+		if isSwiss && table[i].Buchholz != table[j].Buchholz {
+			return table[i].Buchholz > table[j].Buchholz
+		}
+		return table[i].GamesWon > table[j].GamesWon
+	})
+
+	// Within each group still tied, rank by wins against the rest of the group
+	for start := 0; start < len(table); {
+		end := start + 1
+		for end < len(table) && tied(table[end], table[start]) {
+			end++
+		}
+
+		group := table[start:end]
+		groupWins := map[uint]int{}
+		for _, standing := range group {
+			for _, opponent := range group {
+				groupWins[standing.User.ID] += wins[standing.User.ID][opponent.User.ID]
+			}
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			return groupWins[group[i].User.ID] > groupWins[group[j].User.ID]
+		})
+
+		start = end
+	}
+
+	return table
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func testFixture(player1Id, player2Id, winnerId uint, scores ...[2]int) TournamentFixture {
+	fixture := TournamentFixture{Player1ID: &player1Id, WinnerID: &winnerId}
+	if player2Id == 0 {
+		return fixture
+	}
+
+	fixture.Player2ID = &player2Id
+	matchId := uint(1)
+	fixture.MatchID = &matchId
+	fixture.Match = &Match{}
+	for i, score := range scores {
+		fixture.Match.Scores = append(fixture.Match.Scores, GameScore{Number: i + 1, WinnersScore: score[0], LosersScore: score[1]})
+	}
+	return fixture
+}
+
+func TestTournamentStandings(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		entrants int
+		fixtures []TournamentFixture
+		// User IDs from the top of the table down
+		wantOrder  []uint
+		wantPoints []int
+	}{
+		{
+			name:     "on points",
+			format:   TournamentFormatLeague,
+			entrants: 3,
+			fixtures: []TournamentFixture{
+				testFixture(1, 2, 1),
+				testFixture(2, 3, 2),
+				testFixture(3, 1, 1),
+			},
+			wantOrder:  []uint{1, 2, 3},
+			wantPoints: []int{4, 2, 0},
+		},
+		{
+			name:     "ties on games won then head to head",
+			format:   TournamentFormatLeague,
+			entrants: 3,
+			fixtures: []TournamentFixture{
+				testFixture(1, 2, 1, [2]int{11, 5}, [2]int{11, 5}),
+				testFixture(2, 3, 2, [2]int{11, 5}, [2]int{5, 11}, [2]int{11, 5}),
+				testFixture(3, 1, 3, [2]int{11, 5}, [2]int{5, 11}, [2]int{11, 5}),
+			},
+			wantOrder:  []uint{3, 1, 2},
+			wantPoints: []int{2, 2, 2},
+		},
+		{
+			name:     "undecided fixtures don't count",
+			format:   TournamentFormatLeague,
+			entrants: 2,
+			fixtures: []TournamentFixture{
+				{Player1ID: new(uint), Player2ID: new(uint)},
+			},
+			wantOrder:  []uint{1, 2},
+			wantPoints: []int{0, 0},
+		},
+		{
+			name:     "Swiss bye and Buchholz",
+			format:   TournamentFormatSwiss,
+			entrants: 5,
+			fixtures: []TournamentFixture{
+				testFixture(1, 2, 1),
+				testFixture(3, 4, 3),
+				testFixture(5, 0, 5),
+				testFixture(1, 3, 1),
+				testFixture(4, 2, 4),
+			},
+			wantOrder:  []uint{1, 3, 4, 5, 2},
+			wantPoints: []int{4, 2, 2, 2, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tournament := Tournament{Format: tt.format, PointsForWin: 2, Fixtures: tt.fixtures}
+			for i := range tt.entrants {
+				entrant := TournamentEntrant{UserID: uint(i + 1), Seed: i + 1}
+				entrant.User.ID = entrant.UserID
+				tournament.Entrants = append(tournament.Entrants, entrant)
+			}
+
+			order := []uint{}
+			points := []int{}
+			for _, standing := range tournament.Standings() {
+				order = append(order, standing.User.ID)
+				points = append(points, standing.Points)
+			}
+			if !slices.Equal(order, tt.wantOrder) {
+				t.Errorf("table is %v, want %v", order, tt.wantOrder)
+			}
+			if !slices.Equal(points, tt.wantPoints) {
+				t.Errorf("points are %v, want %v", points, tt.wantPoints)
+			}
+		})
+	}
+}
@@ -1,24 +1,55 @@
 package gameprocessor
 
-type cache map[uint]*Game
+// cacheKey identifies a processed game. A seasonId of 0 is the game's all time
+// history.
+type cacheKey struct {
+	gameId   uint
+	seasonId uint
+}
 
-func newCache() *cache {
-	return &cache{}
+type cache struct {
+	entries map[cacheKey]*Game
+	// Bumped every time a game's entries change, so a process that started
+	// before the change can tell its result is out of date
+	generations map[uint]uint64
 }
 
-func (c *cache) setEntry(officeId uint, newEntry *Game) {
-	if c == nil {
-		c = newCache()
+func newCache() *cache {
+	return &cache{
+		entries:     map[cacheKey]*Game{},
+		generations: map[uint]uint64{},
 	}
+}
 
-	(*c)[officeId] = newEntry
+func (c *cache) setEntry(key cacheKey, newEntry *Game) {
+	c.entries[key] = newEntry
+	c.generations[key.gameId]++
 }
 
-func (c *cache) getEntry(officeId uint) *Game {
-	if c == nil {
-		c = newCache()
-		return nil
+// setEntryIfCurrent sets the entry only if the game's entries haven't changed
+// since the generation was read, and reports whether it did.
+func (c *cache) setEntryIfCurrent(key cacheKey, newEntry *Game, generation uint64) bool {
+	if c.generations[key.gameId] != generation {
+		return false
 	}
 
-	return (*c)[officeId]
+	c.setEntry(key, newEntry)
+	return true
+}
+
+func (c *cache) getEntry(key cacheKey) *Game {
+	return c.entries[key]
+}
+
+func (c *cache) generation(gameId uint) uint64 {
+	return c.generations[gameId]
+}
+
+func (c *cache) invalidateGame(gameId uint) {
+	for key := range c.entries {
+		if key.gameId == gameId {
+			delete(c.entries, key)
+		}
+	}
+	c.generations[gameId]++
 }
@@ -2,41 +2,115 @@ package gameprocessor
 
 import (
 	"math"
-)
 
-const (
-	handicapMultiplier = 0.25
-	kFactor            = 32
+	"github.com/RowMur/office-table-tennis/internal/db"
 )
 
-func calculatePointsGainLoss(winners, losers []Player, multiplier float64) int {
-	summedWinnerElo := float64(0)
-	for _, winner := range winners {
-		summedWinnerElo += float64(winner.Points)
+type eloRatingSystem struct {
+	settings db.RatingSettings
+}
+
+func (e eloRatingSystem) NewPlayer(user db.User) Player {
+	return Player{
+		User:        user,
+		Points:      e.settings.StartingPoints,
+		Provisional: e.settings.ProvisionalMatches > 0,
+	}
+}
+
+func (e eloRatingSystem) RateMatch(match db.Match, winners, losers []Player) map[uint]Player {
+	pointsGainLoss := e.calculatePointsGainLoss(winners, losers, 1.0, match.PointDifferential())
+	if match.IsHandicap {
+		pointsGainLoss = e.calculateHandicapPointsGain()
 	}
-	avgWinnerElo := summedWinnerElo / float64(len(winners))
 
-	summedLoserElo := float64(0)
+	rated := map[uint]Player{}
+	for _, winner := range winners {
+		pointsToApply := pointsGainLoss
+
+		// Counts include this match, hence the +1
+		if e.isProvisional(winner.MatchesPlayed() + 1) {
+			pointsToApply = int(math.Round(float64(pointsToApply) * e.settings.ProvisionalMultiplier))
+		}
+
+		winner.Points += pointsToApply
+		winner.Provisional = e.isProvisional(winner.MatchesPlayed() + 1)
+		rated[winner.User.ID] = winner
+	}
 	for _, loser := range losers {
-		summedLoserElo += float64(loser.Points)
+		pointsToApply := pointsGainLoss
+
+		if e.isProvisional(loser.MatchesPlayed() + 1) {
+			pointsToApply = int(math.Round(float64(pointsToApply) * e.settings.ProvisionalMultiplier))
+		}
+
+		if loser.Points-pointsToApply < e.settings.PointsFloor {
+			pointsToApply = max(loser.Points-e.settings.PointsFloor, 0)
+		}
+
+		loser.Points -= pointsToApply
+		loser.Provisional = e.isProvisional(loser.MatchesPlayed() + 1)
+		rated[loser.User.ID] = loser
 	}
-	avgLoserElo := summedLoserElo / float64(len(losers))
+
+	return rated
+}
+
+func (e eloRatingSystem) WinProbability(team, opponents []Player) float64 {
+	return calculateExpectedScore(averagePoints(team), averagePoints(opponents))
+}
+
+func (e eloRatingSystem) isProvisional(matchesPlayed int) bool {
+	return matchesPlayed < e.settings.ProvisionalMatches
+}
+
+func (e eloRatingSystem) calculatePointsGainLoss(winners, losers []Player, multiplier float64, pointDifferential int) int {
+	avgWinnerElo := averagePoints(winners)
+	avgLoserElo := averagePoints(losers)
 
 	expectedScore := calculateExpectedScore(avgWinnerElo, avgLoserElo)
-	pointsGainLoss := calculatePointsGainLossFromExpected(expectedScore, 1, multiplier)
+
+	marginOfVictory := float64(1)
+	if e.settings.MarginOfVictory > 0 && pointDifferential > 0 {
+		marginOfVictory = marginOfVictoryMultiplier(pointDifferential, avgWinnerElo-avgLoserElo, e.settings.MarginOfVictory)
+	}
+
+	pointsGainLoss := e.calculatePointsGainLossFromExpected(expectedScore, 1, multiplier, marginOfVictory)
 
 	return pointsGainLoss
 }
 
-func CalculateHandicapPointsGain() int {
-	basePointsGain := calculatePointsGainLossFromExpected(0.5, 1, handicapMultiplier)
+func (e eloRatingSystem) calculateHandicapPointsGain() int {
+	basePointsGain := e.calculatePointsGainLossFromExpected(0.5, 1, e.settings.HandicapMultiplier, 1)
 	return basePointsGain
 }
 
+// marginOfVictoryReference is the point differential of a typical win, an 11-5
+// game, which moves ratings as much as a match without a score.
+const marginOfVictoryReference = 6
+
+// marginOfVictoryMultiplier is FiveThirtyEight's margin of victory multiplier.
+// Big wins count for more, but less so when the favourite wins, which corrects
+// for the favourite being expected to win by more (autocorrelation). It's scaled
+// so a typical win is worth 1, and blended towards 1 by the strength.
+func marginOfVictoryMultiplier(pointDifferential int, winnerEloDifference float64, strength float64) float64 {
+	multiplier := math.Log(float64(pointDifferential)+1) / math.Log(marginOfVictoryReference+1) *
+		2.2 / (winnerEloDifference*0.001 + 2.2)
+	return 1 + strength*(multiplier-1)
+}
+
+func averagePoints(players []Player) float64 {
+	summedPoints := float64(0)
+	for _, player := range players {
+		summedPoints += float64(player.Points)
+	}
+	return summedPoints / float64(len(players))
+}
+
 func calculateExpectedScore(elo1, elo2 float64) float64 {
 	return 1 / (1 + math.Pow(10, ((elo2-elo1)/400)))
 }
 
-func calculatePointsGainLossFromExpected(expectedScore float64, actualScore float64, multiplier float64) int {
-	return int(math.Round(multiplier * kFactor * (actualScore - expectedScore)))
+func (e eloRatingSystem) calculatePointsGainLossFromExpected(expectedScore float64, actualScore float64, multiplier float64, marginOfVictory float64) int {
+	return int(math.Round(multiplier * marginOfVictory * float64(e.settings.KFactor) * (actualScore - expectedScore)))
 }
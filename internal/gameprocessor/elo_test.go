@@ -0,0 +1,223 @@
+package gameprocessor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+// baselineElo is how matches were rated before the rating settings could be
+// changed, which the default settings have to keep to.
+type baselineElo struct {
+	points map[uint]int
+	played map[uint]int
+}
+
+func (b *baselineElo) rate(winners, losers []uint, isHandicap bool) {
+	average := func(ids []uint) float64 {
+		summed := 0
+		for _, id := range ids {
+			summed += b.points[id]
+		}
+		return float64(summed) / float64(len(ids))
+	}
+
+	expected := 1 / (1 + math.Pow(10, (average(losers)-average(winners))/400))
+	pointsGainLoss := int(math.Round(32 * (1 - expected)))
+	if isHandicap {
+		pointsGainLoss = int(math.Round(0.25 * 32 * 0.5))
+	}
+
+	for _, id := range winners {
+		b.played[id]++
+		pointsToApply := pointsGainLoss
+		if b.played[id] < 20 {
+			pointsToApply *= 2
+		}
+		b.points[id] += pointsToApply
+	}
+	for _, id := range losers {
+		b.played[id]++
+		pointsToApply := pointsGainLoss
+		if b.played[id] < 20 {
+			pointsToApply *= 2
+		}
+		if b.points[id]-pointsToApply < 200 {
+			pointsToApply = b.points[id] - 200
+		}
+		b.points[id] -= pointsToApply
+	}
+}
+
+type eloTestMatch struct {
+	winners    []uint
+	losers     []uint
+	isHandicap bool
+}
+
+func TestEloMatchesBaseline(t *testing.T) {
+	longRun := []eloTestMatch{}
+	for i := range 60 {
+		players := []uint{uint(i%4 + 1), uint((i+1)%4 + 1), uint((i+2)%4 + 1), uint((i+3)%4 + 1)}
+		if i%3 == 0 {
+			longRun = append(longRun, eloTestMatch{winners: players[:2], losers: players[2:]})
+		} else {
+			longRun = append(longRun, eloTestMatch{winners: players[:1], losers: players[1:2], isHandicap: i%7 == 0})
+		}
+	}
+
+	losingRun := []eloTestMatch{}
+	for range 15 {
+		losingRun = append(losingRun, eloTestMatch{winners: []uint{1}, losers: []uint{2}})
+	}
+
+	tests := []struct {
+		name    string
+		matches []eloTestMatch
+	}{
+		{
+			name:    "singles",
+			matches: []eloTestMatch{{winners: []uint{1}, losers: []uint{2}}, {winners: []uint{2}, losers: []uint{1}}, {winners: []uint{1}, losers: []uint{2}}},
+		},
+		{
+			name:    "doubles",
+			matches: []eloTestMatch{{winners: []uint{1, 2}, losers: []uint{3, 4}}, {winners: []uint{1, 3}, losers: []uint{2, 4}}},
+		},
+		{
+			name:    "handicap",
+			matches: []eloTestMatch{{winners: []uint{1}, losers: []uint{2}}, {winners: []uint{2}, losers: []uint{1}, isHandicap: true}},
+		},
+		{
+			name:    "past the provisional matches",
+			matches: longRun,
+		},
+		{
+			name:    "down to the points floor",
+			matches: losingRun,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGame(eloRatingSystem{settings: defaultRatingSettings}, defaultRatingSettings)
+			baseline := baselineElo{points: map[uint]int{}, played: map[uint]int{}}
+
+			for i, m := range tt.matches {
+				for _, id := range append(append([]uint{}, m.winners...), m.losers...) {
+					if _, ok := baseline.points[id]; !ok {
+						baseline.points[id] = 400
+					}
+				}
+
+				g.applyMatch(testMatch(i, m.winners, m.losers, m.isHandicap))
+				baseline.rate(m.winners, m.losers, m.isHandicap)
+
+				for id, want := range baseline.points {
+					if got := g.players[id].Points; got != want {
+						t.Fatalf("after match %d player %d has %d points, want %d", i+1, id, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMarginOfVictoryMultiplier(t *testing.T) {
+	tests := []struct {
+		name                string
+		pointDifferential   int
+		winnerEloDifference float64
+		strength            float64
+		want                float64
+	}{
+		{name: "typical win", pointDifferential: 6, strength: 1, want: 1},
+		{name: "thrashing", pointDifferential: 22, strength: 1, want: 1.6113},
+		{name: "close win", pointDifferential: 2, strength: 1, want: 0.5646},
+		{name: "favourite thrashes", pointDifferential: 22, winnerEloDifference: 400, strength: 1, want: 1.3634},
+		{name: "underdog thrashes", pointDifferential: 22, winnerEloDifference: -400, strength: 1, want: 1.9694},
+		{name: "half strength", pointDifferential: 22, strength: 0.5, want: 1.3057},
+		{name: "no strength", pointDifferential: 22, strength: 0, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := marginOfVictoryMultiplier(tt.pointDifferential, tt.winnerEloDifference, tt.strength)
+			if math.Abs(got-tt.want) > 0.0001 {
+				t.Errorf("got %.4f, want %.4f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEloMarginOfVictory(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores [][2]int
+		want   int
+	}{
+		{name: "unscored", want: 16},
+		{name: "typical win", scores: [][2]int{{11, 5}}, want: 16},
+		{name: "thrashing", scores: [][2]int{{11, 0}, {11, 0}}, want: 26},
+		{name: "close win", scores: [][2]int{{9, 11}, {11, 9}, {12, 10}}, want: 9},
+	}
+
+	settings := defaultRatingSettings
+	settings.ProvisionalMatches = 0
+	settings.MarginOfVictory = 1
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := testMatch(0, []uint{1}, []uint{2}, false)
+			for i, score := range tt.scores {
+				match.Scores = append(match.Scores, db.GameScore{Number: i + 1, WinnersScore: score[0], LosersScore: score[1]})
+			}
+
+			g := newGame(eloRatingSystem{settings: settings}, settings)
+			g.applyMatch(match)
+
+			winner := g.MatchParticipant(match.ID, 1)
+			loser := g.MatchParticipant(match.ID, 2)
+			if winner.PointsApplied != tt.want || loser.PointsApplied != tt.want {
+				t.Errorf("points applied are %d and %d, want %d", winner.PointsApplied, loser.PointsApplied, tt.want)
+			}
+		})
+	}
+}
+
+func TestEloSettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   func(settings *db.RatingSettings)
+		isHandicap bool
+		// Points each player has after player 1 beats player 2 from their
+		// starting points
+		wantWinner int
+		wantLoser  int
+	}{
+		{name: "defaults", settings: func(*db.RatingSettings) {}, wantWinner: 432, wantLoser: 368},
+		{name: "starting points", settings: func(s *db.RatingSettings) { s.StartingPoints = 1000 }, wantWinner: 1032, wantLoser: 968},
+		{name: "k-factor", settings: func(s *db.RatingSettings) { s.KFactor = 20 }, wantWinner: 420, wantLoser: 380},
+		{name: "no provisional matches", settings: func(s *db.RatingSettings) { s.ProvisionalMatches = 0 }, wantWinner: 416, wantLoser: 384},
+		{name: "provisional multiplier", settings: func(s *db.RatingSettings) { s.ProvisionalMultiplier = 1.5 }, wantWinner: 424, wantLoser: 376},
+		{name: "points floor", settings: func(s *db.RatingSettings) { s.PointsFloor = 390 }, wantWinner: 432, wantLoser: 390},
+		{name: "handicap multiplier", settings: func(s *db.RatingSettings) { s.HandicapMultiplier = 0.5 }, isHandicap: true, wantWinner: 416, wantLoser: 384},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := defaultRatingSettings
+			tt.settings(&settings)
+
+			g := newGame(eloRatingSystem{settings: settings}, settings)
+			g.applyMatch(testMatch(0, []uint{1}, []uint{2}, tt.isHandicap))
+
+			if got := g.players[1].Points; got != tt.wantWinner {
+				t.Errorf("winner has %d points, want %d", got, tt.wantWinner)
+			}
+			if got := g.players[2].Points; got != tt.wantLoser {
+				t.Errorf("loser has %d points, want %d", got, tt.wantLoser)
+			}
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package gameprocessor
 
 import (
+	"maps"
+	"slices"
 	"sort"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
 )
 
 type Game struct {
@@ -9,15 +14,157 @@ type Game struct {
 	players                map[uint]Player
 	playerPairings         *playerCombinations
 	playerOpposingPairings *playerCombinations
+	// Each player's matches in the order they were played
+	playerMatches    map[uint][]uint
+	ratingSystem     RatingSystem
+	inactivityWindow time.Duration
+	// When set, the game is as it stood at that time instead of now
+	asOf time.Time
+	// When the most recent match processed was played, and which it was, as
+	// matches played at the same time are processed in the order they were logged
+	lastMatchAt time.Time
+	lastMatchId uint
 }
 
-func newGame() Game {
+func newGame(ratingSystem RatingSystem, settings db.RatingSettings) Game {
 	return Game{
 		matches:                map[uint]*processedMatch{},
 		players:                map[uint]Player{},
 		playerPairings:         newPlayerCombinations(),
 		playerOpposingPairings: newPlayerCombinations(),
+		playerMatches:          map[uint][]uint{},
+		ratingSystem:           ratingSystem,
+		inactivityWindow:       time.Duration(settings.InactivityWeeks) * 7 * 24 * time.Hour,
+	}
+}
+
+func (g *Game) clone() *Game {
+	clone := *g
+	clone.matches = maps.Clone(g.matches)
+	clone.players = maps.Clone(g.players)
+	clone.playerPairings = g.playerPairings.clone()
+	clone.playerOpposingPairings = g.playerOpposingPairings.clone()
+	clone.playerMatches = map[uint][]uint{}
+	for userId, matchIds := range g.playerMatches {
+		clone.playerMatches[userId] = slices.Clone(matchIds)
+	}
+	return &clone
+}
+
+// applyMatch processes a match on top of everything processed so far. Matches
+// must be applied in the order they were played.
+func (g *Game) applyMatch(match db.Match) {
+	cachedMatch := processedMatch{
+		Participants: map[uint]*ProcessedMatchParticipant{},
+		PlayedAt:     match.PlayedAt,
+	}
+
+	winners := []Player{}
+	losers := []Player{}
+	for _, participant := range match.Participants {
+		if _, ok := g.players[participant.UserID]; !ok {
+			g.players[participant.UserID] = g.ratingSystem.NewPlayer(participant.User)
+		}
+
+		if participant.Result == "win" {
+			winners = append(winners, g.players[participant.UserID])
+		} else {
+			losers = append(losers, g.players[participant.UserID])
+		}
 	}
+
+	rated := g.ratingSystem.RateMatch(match, winners, losers)
+
+	for _, winner := range winners {
+		for _, w := range winners {
+			if w.User.ID > winner.User.ID {
+				g.playerPairings.addMatch(match.ID, winner, w, true, true)
+			}
+		}
+		for _, l := range losers {
+			g.playerOpposingPairings.addMatch(match.ID, winner, l, true, false)
+		}
+
+		ratedWinner := rated[winner.User.ID]
+		ratedWinner.WinCount++
+		ratedWinner.LastPlayed = match.PlayedAt
+
+		cachedMatch.Participants[winner.User.ID] = &ProcessedMatchParticipant{
+			UserID:        winner.User.ID,
+			Win:           true,
+			PointsApplied: ratedWinner.Points - winner.Points,
+			PointsBefore:  winner.Points,
+			PointsAfter:   ratedWinner.Points,
+		}
+		if ratedWinner.Points > ratedWinner.RecordPoints {
+			ratedWinner.RecordPoints = ratedWinner.Points
+			ratedWinner.RecordPointsDate = match.PlayedAt
+		}
+		g.players[winner.User.ID] = ratedWinner
+	}
+	for _, loser := range losers {
+		for _, l := range losers {
+			if l.User.ID > loser.User.ID {
+				g.playerPairings.addMatch(match.ID, loser, l, false, false)
+			}
+		}
+
+		ratedLoser := rated[loser.User.ID]
+		ratedLoser.LossCount++
+		ratedLoser.LastPlayed = match.PlayedAt
+
+		cachedMatch.Participants[loser.User.ID] = &ProcessedMatchParticipant{
+			UserID:        loser.User.ID,
+			Win:           false,
+			PointsApplied: loser.Points - ratedLoser.Points,
+			PointsBefore:  loser.Points,
+			PointsAfter:   ratedLoser.Points,
+		}
+		if ratedLoser.Points > ratedLoser.RecordPoints {
+			ratedLoser.RecordPoints = ratedLoser.Points
+			ratedLoser.RecordPointsDate = match.PlayedAt
+		}
+		g.players[loser.User.ID] = ratedLoser
+	}
+
+	for userId := range cachedMatch.Participants {
+		g.playerMatches[userId] = append(g.playerMatches[userId], match.ID)
+	}
+
+	g.matches[match.ID] = &cachedMatch
+	g.lastMatchAt = match.PlayedAt
+	g.lastMatchId = match.ID
+}
+
+// withApprovedMatch returns a copy of the game with the newly approved match
+// applied, or nil if the match comes before matches already processed and the
+// history has to be replayed instead.
+func (g *Game) withApprovedMatch(match db.Match) *Game {
+	if match.PlayedAt.Before(g.lastMatchAt) || (match.PlayedAt.Equal(g.lastMatchAt) && match.ID < g.lastMatchId) {
+		return nil
+	}
+
+	updated := g.clone()
+	updated.applyMatch(match)
+	return updated
+}
+
+// current returns the player as they stand now, rather than as of their last
+// match. Players are active if they've played within the inactivity window.
+func (g *Game) current(player Player) Player {
+	at := time.Now()
+	if !g.asOf.IsZero() {
+		at = g.asOf
+	}
+
+	player.IsActive = at.Sub(player.LastPlayed) < g.inactivityWindow
+
+	decayer, ok := g.ratingSystem.(inactivityDecayer)
+	if !ok {
+		return player
+	}
+
+	return decayer.Decay(player, at)
 }
 
 func (g *Game) MatchesPlayed() int {
@@ -96,6 +243,36 @@ func (g *Game) MostCommonPairingForPlayer(p Player) *playerCombination {
 	return nil
 }
 
+// PartnershipRankings is the best teammate pairs by win rate, for pairs that
+// have played at least minMatches together.
+func (g *Game) PartnershipRankings(minMatches int) []playerCombination {
+	return g.playerPairings.rankedPlayerCombinations(minMatches)
+}
+
+// BestPartnerForPlayer is the teammate the player has won the most with.
+func (g *Game) BestPartnerForPlayer(p Player) *playerCombination {
+	var best *playerCombination
+	for _, pc := range g.playerPairings.orderedPlayerCombinationsForUser(p.User.ID) {
+		if pc.WinCount > 0 && (best == nil || pc.WinCount > best.WinCount) {
+			best = &pc
+		}
+	}
+
+	return best
+}
+
+// WorstPartnerForPlayer is the teammate the player has lost the most with.
+func (g *Game) WorstPartnerForPlayer(p Player) *playerCombination {
+	var worst *playerCombination
+	for _, pc := range g.playerPairings.orderedPlayerCombinationsForUser(p.User.ID) {
+		if pc.LossCount > 0 && (worst == nil || pc.LossCount > worst.LossCount) {
+			worst = &pc
+		}
+	}
+
+	return worst
+}
+
 func (g *Game) MostCommonOpponentForPlayer(p Player) *playerCombination {
 	pairings := g.playerOpposingPairings.orderedPlayerCombinationsForUser(p.User.ID)
 	if len(pairings) > 0 {
@@ -105,22 +282,49 @@ func (g *Game) MostCommonOpponentForPlayer(p Player) *playerCombination {
 	return nil
 }
 
+// OpposingMatches is every match the two players have played against each other.
+func (g *Game) OpposingMatches(player1Id, player2Id uint) []uint {
+	pairing, ok := (*g.playerOpposingPairings)[player1Id][player2Id]
+	if !ok {
+		return []uint{}
+	}
+
+	return slices.Clone(pairing.matches)
+}
+
 func (g *Game) GetMatch(matchId uint) *processedMatch {
 	return g.matches[matchId]
 }
 
+// MatchParticipant is how the match went for one of its participants, or nil
+// if the match hasn't been processed.
+func (g *Game) MatchParticipant(matchId, userId uint) *ProcessedMatchParticipant {
+	if g == nil {
+		return nil
+	}
+
+	match := g.matches[matchId]
+	if match == nil {
+		return nil
+	}
+
+	return match.Participants[userId]
+}
+
 func (g *Game) GetPlayer(userId uint) *Player {
 	player, ok := g.players[userId]
 	if !ok {
 		return nil
 	}
 
+	player = g.current(player)
 	return &player
 }
 
 func (g *Game) RankedPlayers() []Player {
 	players := []Player{}
 	for _, player := range g.players {
+		player = g.current(player)
 		if player.IsActive {
 			players = append(players, player)
 		}
@@ -143,3 +347,50 @@ func (g *Game) RankedPlayers() []Player {
 
 	return players
 }
+
+type RatingPoint struct {
+	MatchID uint
+	Date    time.Time
+	Points  int
+}
+
+// RatingHistory is the player's points after each of their matches, starting
+// with the points they had before their first.
+func (g *Game) RatingHistory(userId uint) []RatingPoint {
+	history := []RatingPoint{}
+	for _, matchId := range g.playerMatches[userId] {
+		match := g.matches[matchId]
+		participant := match.Participants[userId]
+
+		if len(history) == 0 {
+			history = append(history, RatingPoint{Date: match.PlayedAt, Points: participant.PointsBefore})
+		}
+
+		history = append(history, RatingPoint{
+			MatchID: matchId,
+			Date:    match.PlayedAt,
+			Points:  participant.PointsAfter,
+		})
+	}
+
+	return history
+}
+
+// WinProbability is the chance of the team beating the opponents as things
+// stand. Anyone who hasn't played yet is rated as a new player would be.
+func (g *Game) WinProbability(team, opponents []db.User) float64 {
+	return g.ratingSystem.WinProbability(g.currentPlayers(team), g.currentPlayers(opponents))
+}
+
+func (g *Game) currentPlayers(users []db.User) []Player {
+	players := []Player{}
+	for _, user := range users {
+		player := g.GetPlayer(user.ID)
+		if player == nil {
+			players = append(players, g.ratingSystem.NewPlayer(user))
+			continue
+		}
+		players = append(players, *player)
+	}
+	return players
+}
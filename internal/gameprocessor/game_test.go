@@ -0,0 +1,212 @@
+package gameprocessor
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func TestWithApprovedMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		processed []db.Match
+		approved  db.Match
+		// Whether the match has to be replayed rather than applied on top
+		wantReplay bool
+	}{
+		{
+			name:     "first match",
+			approved: testMatch(0, []uint{1}, []uint{2}, false),
+		},
+		{
+			name:      "new players",
+			processed: []db.Match{testMatch(0, []uint{1}, []uint{2}, false)},
+			approved:  testMatch(1, []uint{3, 4}, []uint{1, 2}, false),
+		},
+		{
+			name: "existing players",
+			processed: []db.Match{
+				testMatch(0, []uint{1}, []uint{2}, false),
+				testMatch(1, []uint{1, 3}, []uint{2, 4}, false),
+			},
+			approved: testMatch(2, []uint{2, 4}, []uint{1, 3}, false),
+		},
+		{
+			name:      "played at the same time as the last match",
+			processed: []db.Match{testMatch(0, []uint{1}, []uint{2}, false)},
+			approved: func() db.Match {
+				match := testMatch(1, []uint{2}, []uint{1}, false)
+				match.PlayedAt = testStart
+				return match
+			}(),
+		},
+		{
+			name: "played at the same time as a match logged after it",
+			processed: []db.Match{func() db.Match {
+				match := testMatch(1, []uint{1}, []uint{2}, false)
+				match.PlayedAt = testStart
+				return match
+			}()},
+			approved:   testMatch(0, []uint{2}, []uint{1}, false),
+			wantReplay: true,
+		},
+		{
+			name: "back-dated",
+			processed: []db.Match{
+				testMatch(0, []uint{1}, []uint{2}, false),
+				testMatch(2, []uint{1}, []uint{2}, false),
+			},
+			approved:   testMatch(1, []uint{2}, []uint{1}, false),
+			wantReplay: true,
+		},
+	}
+
+	ratingSystems := []string{db.RatingSystemElo, db.RatingSystemGlicko, db.RatingSystemTrueSkill}
+
+	for _, tt := range tests {
+		for _, ratingSystem := range ratingSystems {
+			t.Run(tt.name+"/"+ratingSystem, func(t *testing.T) {
+				game := db.Game{RatingSystem: ratingSystem, RatingSettings: defaultRatingSettings}
+				process := func(matches []db.Match) *Game {
+					g := newGame(newRatingSystem(game), defaultRatingSettings)
+					for _, match := range matches {
+						g.applyMatch(match)
+					}
+					return &g
+				}
+
+				all := append(slices.Clone(tt.processed), tt.approved)
+				slices.SortFunc(all, func(a, b db.Match) int {
+					if c := a.PlayedAt.Compare(b.PlayedAt); c != 0 {
+						return c
+					}
+					return int(a.ID) - int(b.ID)
+				})
+
+				cached := process(tt.processed)
+				got := cached.withApprovedMatch(tt.approved)
+				if (got == nil) != tt.wantReplay {
+					t.Fatalf("replay needed is %t, want %t", got == nil, tt.wantReplay)
+				}
+				if got == nil {
+					// Falls back to processing the whole history
+					got = process(all)
+				}
+
+				want := process(all)
+				if !reflect.DeepEqual(got.players, want.players) {
+					t.Errorf("players are %+v, want %+v", got.players, want.players)
+				}
+				if !reflect.DeepEqual(got.matches, want.matches) {
+					t.Errorf("matches differ from processing them all")
+				}
+				if !reflect.DeepEqual(got.playerMatches, want.playerMatches) {
+					t.Errorf("player matches are %v, want %v", got.playerMatches, want.playerMatches)
+				}
+				if !reflect.DeepEqual(got.playerPairings, want.playerPairings) || !reflect.DeepEqual(got.playerOpposingPairings, want.playerOpposingPairings) {
+					t.Errorf("pairings differ from processing them all")
+				}
+
+				if cached.MatchesPlayed() != len(tt.processed) {
+					t.Errorf("cached game has %d matches, want it left at %d", cached.MatchesPlayed(), len(tt.processed))
+				}
+			})
+		}
+	}
+}
+
+func TestRankedPlayersActivity(t *testing.T) {
+	week := 7 * 24 * time.Hour
+
+	tests := []struct {
+		name string
+		asOf time.Time
+		want []uint
+	}{
+		{
+			name: "everyone played recently",
+			asOf: testStart.Add(5 * week),
+			want: []uint{1, 2, 3},
+		},
+		{
+			name: "one past the inactivity window",
+			asOf: testStart.Add(9 * week),
+			want: []uint{1, 3},
+		},
+		{
+			name: "everyone past the inactivity window",
+			asOf: testStart.Add(13 * week),
+			want: []uint{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGame(eloRatingSystem{settings: defaultRatingSettings}, defaultRatingSettings)
+			g.applyMatch(testMatch(0, []uint{1}, []uint{2}, false))
+
+			later := testMatch(1, []uint{3}, []uint{1}, false)
+			later.PlayedAt = testStart.Add(4 * week)
+			g.applyMatch(later)
+
+			g.asOf = tt.asOf
+
+			got := []uint{}
+			for _, player := range g.RankedPlayers() {
+				got = append(got, player.User.ID)
+			}
+			slices.Sort(got)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ranked players are %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatingHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		userId  uint
+		matches []db.Match
+		want    []RatingPoint
+	}{
+		{
+			name:    "never played",
+			userId:  3,
+			matches: []db.Match{testMatch(0, []uint{1}, []uint{2}, false)},
+			want:    []RatingPoint{},
+		},
+		{
+			name:   "only their own matches",
+			userId: 1,
+			matches: []db.Match{
+				testMatch(0, []uint{1}, []uint{2}, false),
+				testMatch(1, []uint{2}, []uint{3}, false),
+				testMatch(2, []uint{3}, []uint{1}, false),
+			},
+			want: []RatingPoint{
+				{Date: testStart, Points: 400},
+				{MatchID: 1, Date: testStart, Points: 432},
+				{MatchID: 3, Date: testStart.Add(2 * time.Hour), Points: 394},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGame(eloRatingSystem{settings: defaultRatingSettings}, defaultRatingSettings)
+			for _, match := range tt.matches {
+				g.applyMatch(match)
+			}
+
+			got := g.RatingHistory(tt.userId)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
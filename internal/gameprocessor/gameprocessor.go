@@ -2,20 +2,16 @@ package gameprocessor
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/RowMur/office-table-tennis/internal/db"
 )
 
-const (
-	matchesWithDoublePoints = 20
-	eloStartingPoints       = 400
-	eloLowerBound           = 200
-)
-
 type GameProcessor struct {
-	db    db.Database
-	cache *cache
+	db      db.Database
+	cache   *cache
+	cacheMu sync.Mutex
 }
 
 func NewGameProcessor(db db.Database) *GameProcessor {
@@ -27,150 +23,166 @@ func NewGameProcessor(db db.Database) *GameProcessor {
 
 type processedMatch struct {
 	Participants map[uint]*ProcessedMatchParticipant
+	PlayedAt     time.Time
 }
 
 type ProcessedMatchParticipant struct {
 	UserID        uint
 	Win           bool
 	PointsApplied int
+	PointsBefore  int
+	PointsAfter   int
 }
 
-func (gp *GameProcessor) Process(officeId uint) (*Game, error) {
+func (gp *GameProcessor) Process(gameId uint) (*Game, error) {
 	startTime := time.Now()
 	defer func() {
 		fmt.Printf("GetElos: %s\n", time.Since(startTime))
 	}()
 
-	if gp.cache != nil {
-		entry := gp.cache.getEntry(officeId)
-		if entry != nil {
-			return entry, nil
-		}
+	entry := gp.getCachedGame(cacheKey{gameId: gameId})
+	if entry != nil {
+		return entry, nil
+	}
+
+	return gp.process(gameId, nil)
+}
+
+// ProcessSeason is like Process, but only takes into account the game's matches
+// played during the season. Everyone starts the season afresh.
+func (gp *GameProcessor) ProcessSeason(gameId uint, season db.Season) (*Game, error) {
+	entry := gp.getCachedGame(cacheKey{gameId: gameId, seasonId: season.ID})
+	if entry != nil {
+		return entry, nil
 	}
 
-	return gp.process(officeId)
+	return gp.process(gameId, &season)
 }
 
-func (gp *GameProcessor) process(officeId uint) (*Game, error) {
+func (gp *GameProcessor) getCachedGame(key cacheKey) *Game {
+	gp.cacheMu.Lock()
+	defer gp.cacheMu.Unlock()
+
+	return gp.cache.getEntry(key)
+}
+
+// process works the game out from its matches and caches it. If the cached game
+// changes while it's working, say a match was applied to it, the result is
+// returned but not cached, as it may be missing the change.
+func (gp *GameProcessor) process(gameId uint, season *db.Season) (*Game, error) {
+	gp.cacheMu.Lock()
+	generation := gp.cache.generation(gameId)
+	gp.cacheMu.Unlock()
+
+	game := db.Game{}
+	err := gp.db.C.First(&game, gameId).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query := gp.db.C.Where("game_id = ?", gameId).
+		Where("state = ?", db.MatchStateApproved)
+	if season != nil {
+		query = query.Where("played_at >= ? AND played_at < ?", season.StartDate, season.EndDate)
+	}
+
 	matches := []db.Match{}
-	err := gp.db.C.Where("office_id = ?", officeId).
-		Where("state NOT IN (?)", db.MatchStatePending).
-		Order("created_at").
+	err = query.
+		Order("played_at, id").
 		Preload("Participants.User").
+		Preload("Scores").
 		Find(&matches).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	g := newGame()
+	g := newGame(newRatingSystem(game), game.RatingSettings)
+
+	// Players are active relative to the end of a season that has finished
+	if season != nil && season.HasEnded() {
+		g.asOf = season.EndDate
+	}
 
-	players := map[uint]Player{}
 	for _, match := range matches {
-		cachedMatch := processedMatch{
-			Participants: map[uint]*ProcessedMatchParticipant{},
-		}
-
-		timeSinceMatch := time.Since(match.CreatedAt)
-		activatePlayers := timeSinceMatch < 8*7*24*time.Hour // 8 weeks
-
-		winners := []Player{}
-		losers := []Player{}
-		for _, participant := range match.Participants {
-
-			if player, ok := players[participant.UserID]; !ok {
-				players[participant.UserID] = Player{
-					User:     participant.User,
-					Points:   eloStartingPoints,
-					IsActive: activatePlayers,
-				}
-			} else {
-				if !player.IsActive && activatePlayers {
-					player.IsActive = true
-				}
-				players[participant.UserID] = player
-			}
-
-			if participant.Result == "win" {
-				winners = append(winners, players[participant.UserID])
-			} else {
-				losers = append(losers, players[participant.UserID])
-			}
-		}
-
-		pointsGainLoss := calculatePointsGainLoss(winners, losers, 1.0)
-		if match.IsHandicap {
-			pointsGainLoss = CalculateHandicapPointsGain()
-		}
-
-		for _, winner := range winners {
-			for _, w := range winners {
-				if w.User.ID > winner.User.ID {
-					g.playerPairings.addMatch(match.ID, winner, w)
-				}
-			}
-			for _, l := range losers {
-				g.playerOpposingPairings.addMatch(match.ID, winner, l)
-			}
-
-			winner.WinCount++
-			pointsToApply := pointsGainLoss
-
-			if winner.MatchesPlayed() < matchesWithDoublePoints {
-				pointsToApply = pointsToApply * 2
-			}
-
-			cachedMatch.Participants[winner.User.ID] = &ProcessedMatchParticipant{
-				UserID:        winner.User.ID,
-				Win:           true,
-				PointsApplied: pointsToApply,
-			}
-			winner.Points += pointsToApply
-			if winner.Points > winner.RecordPoints {
-				winner.RecordPoints = winner.Points
-				winner.RecordPointsDate = match.CreatedAt
-			}
-			players[winner.User.ID] = winner
-		}
-		for _, loser := range losers {
-			for _, l := range losers {
-				if l.User.ID > loser.User.ID {
-					g.playerPairings.addMatch(match.ID, loser, l)
-				}
-			}
-
-			loser.LossCount++
-			pointsToApply := pointsGainLoss
-
-			if loser.MatchesPlayed() < matchesWithDoublePoints {
-				pointsToApply = pointsToApply * 2
-			}
-
-			if loser.Points-pointsToApply < eloLowerBound {
-				pointsToApply = loser.Points - eloLowerBound
-			}
-
-			cachedMatch.Participants[loser.User.ID] = &ProcessedMatchParticipant{
-				UserID:        loser.User.ID,
-				Win:           false,
-				PointsApplied: pointsToApply,
-			}
-			loser.Points -= pointsToApply
-			if loser.Points > loser.RecordPoints {
-				loser.RecordPoints = loser.Points
-				loser.RecordPointsDate = match.CreatedAt
-			}
-			players[loser.User.ID] = loser
-		}
-
-		g.matches[match.ID] = &cachedMatch
+		g.applyMatch(match)
 	}
 
-	g.players = players
-	gp.cache.setEntry(officeId, &g)
+	key := cacheKey{gameId: gameId}
+	if season != nil {
+		key.seasonId = season.ID
+	}
+
+	gp.cacheMu.Lock()
+	isCurrent := gp.cache.setEntryIfCurrent(key, &g, generation)
+	gp.cacheMu.Unlock()
+
+	if isCurrent && season == nil {
+		gp.saveSnapshots(game.OfficeID, game.ID, &g, nil)
+	}
 	return &g, nil
 }
 
-func (gp GameProcessor) InvalidateGameCache(gameId uint) {
-	(*gp.cache)[gameId] = nil
+// ApplyApprovedMatch brings the cached game up to date with a newly approved
+// match without replaying the whole history. If the match belongs before matches
+// that have already been processed, the history is replayed on the next Process.
+func (gp *GameProcessor) ApplyApprovedMatch(matchId uint) error {
+	match := db.Match{}
+	err := gp.db.C.Preload("Participants.User").
+		Preload("Scores").
+		First(&match, matchId).Error
+	if err != nil {
+		return err
+	}
+
+	gp.cacheMu.Lock()
+
+	key := cacheKey{gameId: match.GameID}
+	entry := gp.cache.getEntry(key)
+
+	// Seasons are cheap to replay, so don't bother keeping them up to date
+	gp.cache.invalidateGame(match.GameID)
+
+	if entry != nil && entry.GetMatch(match.ID) != nil {
+		// Already picked up by a replay
+		gp.cache.setEntry(key, entry)
+		gp.cacheMu.Unlock()
+		return nil
+	}
+
+	// Other requests may be reading the cached game, so update a copy
+	var updated *Game
+	if entry != nil {
+		updated = entry.withApprovedMatch(match)
+	}
+
+	if updated == nil {
+		gp.cacheMu.Unlock()
+
+		// Replay now so the match's snapshots are saved
+		_, err = gp.process(match.GameID, nil)
+		return err
+	}
+
+	gp.cache.setEntry(key, updated)
+	gp.cacheMu.Unlock()
+
+	gp.saveSnapshots(match.OfficeID, match.GameID, updated, []uint{match.ID})
+	return nil
+}
+
+func (gp *GameProcessor) InvalidateGameCache(gameId uint) {
+	gp.cacheMu.Lock()
+	defer gp.cacheMu.Unlock()
+
+	gp.cache.invalidateGame(gameId)
+}
+
+// Replay throws away everything worked out for the game and processes its whole
+// history again. It's for when an approved match has been changed or voided, so
+// every later rating moves with it. Snapshots that no longer agree are
+// superseded.
+func (gp *GameProcessor) Replay(gameId uint) (*Game, error) {
+	gp.InvalidateGameCache(gameId)
+	return gp.process(gameId, nil)
 }
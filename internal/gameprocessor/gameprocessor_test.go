@@ -0,0 +1,53 @@
+package gameprocessor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+// defaultRatingSettings are the column defaults a new game gets.
+var defaultRatingSettings = db.RatingSettings{
+	StartingPoints:        400,
+	KFactor:               32,
+	PointsFloor:           200,
+	ProvisionalMatches:    20,
+	ProvisionalMultiplier: 2,
+	HandicapMultiplier:    0.25,
+	InactivityWeeks:       8,
+}
+
+var testStart = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+func testUser(id uint) db.User {
+	user := db.User{Username: fmt.Sprintf("player%d", id)}
+	user.ID = id
+	return user
+}
+
+func testPlayers(ids ...uint) []Player {
+	players := []Player{}
+	for _, id := range ids {
+		players = append(players, Player{User: testUser(id)})
+	}
+	return players
+}
+
+// testMatch is a match between the winners and losers, the nth played.
+func testMatch(n int, winners, losers []uint, isHandicap bool) db.Match {
+	match := db.Match{
+		PlayedAt:   testStart.Add(time.Duration(n) * time.Hour),
+		IsHandicap: isHandicap,
+		State:      db.MatchStateApproved,
+	}
+	match.ID = uint(n + 1)
+
+	for _, id := range winners {
+		match.Participants = append(match.Participants, db.MatchParticipant{UserID: id, User: testUser(id), Result: db.MatchResultWin})
+	}
+	for _, id := range losers {
+		match.Participants = append(match.Participants, db.MatchParticipant{UserID: id, User: testUser(id), Result: db.MatchResultLoss})
+	}
+	return match
+}
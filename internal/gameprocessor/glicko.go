@@ -0,0 +1,169 @@
+package gameprocessor
+
+import (
+	"math"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+// Glicko-2 as described in http://www.glicko.net/glicko/glicko2.pdf. Every match
+// is treated as its own rating period, and a team is rated against the average of
+// the opposing team. The office's starting points are used in place of 1500.
+const (
+	glickoScale                = 173.7178
+	glickoStartingDeviation    = 350
+	glickoStartingVolatility   = 0.06
+	glickoTau                  = 0.5
+	glickoConvergenceTolerance = 0.000001
+	glickoRatingPeriod         = 7 * 24 * time.Hour
+	glickoProvisionalDeviation = 110
+)
+
+type glickoRatingSystem struct {
+	settings db.RatingSettings
+}
+
+func (gs glickoRatingSystem) NewPlayer(user db.User) Player {
+	return Player{
+		User:            user,
+		Points:          gs.settings.StartingPoints,
+		Rating:          float64(gs.settings.StartingPoints),
+		RatingDeviation: glickoStartingDeviation,
+		Volatility:      glickoStartingVolatility,
+		Provisional:     true,
+	}
+}
+
+func (gs glickoRatingSystem) RateMatch(match db.Match, winners, losers []Player) map[uint]Player {
+	decayedWinners := []Player{}
+	for _, winner := range winners {
+		decayedWinners = append(decayedWinners, gs.Decay(winner, match.PlayedAt))
+	}
+	decayedLosers := []Player{}
+	for _, loser := range losers {
+		decayedLosers = append(decayedLosers, gs.Decay(loser, match.PlayedAt))
+	}
+
+	winnersMu, winnersPhi := gs.composite(decayedWinners)
+	losersMu, losersPhi := gs.composite(decayedLosers)
+
+	rated := map[uint]Player{}
+	for _, winner := range decayedWinners {
+		rated[winner.User.ID] = gs.update(winner, losersMu, losersPhi, 1, match.IsHandicap)
+	}
+	for _, loser := range decayedLosers {
+		rated[loser.User.ID] = gs.update(loser, winnersMu, winnersPhi, 0, match.IsHandicap)
+	}
+
+	return rated
+}
+
+func (gs glickoRatingSystem) WinProbability(team, opponents []Player) float64 {
+	teamMu, teamPhi := gs.composite(team)
+	opponentsMu, opponentsPhi := gs.composite(opponents)
+
+	// Both sides' uncertainty counts when neither result is known
+	phi := math.Sqrt(teamPhi*teamPhi + opponentsPhi*opponentsPhi)
+	g := 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+	return 1 / (1 + math.Exp(-g*(teamMu-opponentsMu)))
+}
+
+// Decay grows a player's rating deviation for every rating period they haven't
+// played in, so ratings of inactive players become less certain over time.
+func (glickoRatingSystem) Decay(player Player, at time.Time) Player {
+	if player.LastPlayed.IsZero() || !at.After(player.LastPlayed) {
+		return player
+	}
+
+	periods := float64(at.Sub(player.LastPlayed)) / float64(glickoRatingPeriod)
+	phi := player.RatingDeviation / glickoScale
+	phi = math.Sqrt(phi*phi + periods*player.Volatility*player.Volatility)
+
+	player.RatingDeviation = math.Min(phi*glickoScale, glickoStartingDeviation)
+	player.Provisional = player.RatingDeviation > glickoProvisionalDeviation
+	return player
+}
+
+func (gs glickoRatingSystem) mu(player Player) float64 {
+	return (player.Rating - float64(gs.settings.StartingPoints)) / glickoScale
+}
+
+func (gs glickoRatingSystem) composite(players []Player) (mu, phi float64) {
+	summedMu := float64(0)
+	summedPhiSquared := float64(0)
+	for _, player := range players {
+		playerPhi := player.RatingDeviation / glickoScale
+		summedMu += gs.mu(player)
+		summedPhiSquared += playerPhi * playerPhi
+	}
+
+	n := float64(len(players))
+	return summedMu / n, math.Sqrt(summedPhiSquared / n)
+}
+
+func (gs glickoRatingSystem) update(player Player, opponentMu, opponentPhi, score float64, isHandicap bool) Player {
+	mu := gs.mu(player)
+	phi := player.RatingDeviation / glickoScale
+
+	g := 1 / math.Sqrt(1+3*opponentPhi*opponentPhi/(math.Pi*math.Pi))
+	expected := 1 / (1 + math.Exp(-g*(mu-opponentMu)))
+	if isHandicap {
+		// Handicaps are there to even the match up
+		expected = 0.5
+	}
+
+	v := 1 / (g * g * expected * (1 - expected))
+	delta := v * g * (score - expected)
+
+	sigma := glickoVolatility(phi, player.Volatility, delta, v)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*g*(score-expected)
+
+	player.Rating = newMu*glickoScale + float64(gs.settings.StartingPoints)
+	player.RatingDeviation = newPhi * glickoScale
+	player.Volatility = sigma
+	player.Points = int(math.Round(player.Rating))
+	player.Provisional = player.RatingDeviation > glickoProvisionalDeviation
+	return player
+}
+
+// glickoVolatility finds the new volatility using the Illinois algorithm from
+// step 5 of the Glicko-2 paper.
+func glickoVolatility(phi, sigma, delta, v float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		return ex*(delta*delta-phi*phi-v-ex)/(2*math.Pow(phi*phi+v+ex, 2)) - (x-a)/(glickoTau*glickoTau)
+	}
+
+	A := a
+	B := float64(0)
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := float64(1)
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		B = a - k*glickoTau
+	}
+
+	fA := f(A)
+	fB := f(B)
+	for math.Abs(B-A) > glickoConvergenceTolerance {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A = B
+			fA = fB
+		} else {
+			fA = fA / 2
+		}
+		B = C
+		fB = fC
+	}
+
+	return math.Exp(A / 2)
+}
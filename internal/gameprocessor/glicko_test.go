@@ -0,0 +1,60 @@
+package gameprocessor
+
+import "testing"
+
+// The example in the Glicko-2 paper, a 1500 player with a deviation of 200 and
+// a volatility of 0.06 who beat a 1400 player and lost to a 1550 and a 1700
+// player
+const (
+	glickoPaperPhi   = 200 / glickoScale
+	glickoPaperV     = 1.7785
+	glickoPaperDelta = -0.4834
+)
+
+func TestGlickoVolatility(t *testing.T) {
+	tests := []struct {
+		name     string
+		phi      float64
+		sigma    float64
+		delta    float64
+		v        float64
+		min, max float64
+	}{
+		{
+			name:  "paper example",
+			phi:   glickoPaperPhi,
+			sigma: 0.06,
+			delta: glickoPaperDelta,
+			v:     glickoPaperV,
+			min:   0.05999 - 0.00001,
+			max:   0.05999 + 0.00001,
+		},
+		{
+			name:  "result as expected",
+			phi:   glickoPaperPhi,
+			sigma: 0.06,
+			delta: 0,
+			v:     glickoPaperV,
+			min:   0.059,
+			max:   0.06,
+		},
+		{
+			name:  "big upset",
+			phi:   30 / glickoScale,
+			sigma: 0.06,
+			delta: 4,
+			v:     glickoPaperV,
+			min:   0.06,
+			max:   0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := glickoVolatility(tt.phi, tt.sigma, tt.delta, tt.v)
+			if got < tt.min || got > tt.max {
+				t.Errorf("glickoVolatility() = %.6f, want between %.6f and %.6f", got, tt.min, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package gameprocessor
+
+import (
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+type HeadToHead struct {
+	Player1 db.User
+	Player2 db.User
+	// Every match the two played on opposite sides, oldest first
+	Meetings       []HeadToHeadMeeting
+	Player1Wins    int
+	Player2Wins    int
+	WinProbability float64
+
+	CurrentStreak        Streak
+	Player1LongestStreak int
+	Player2LongestStreak int
+}
+
+type HeadToHeadMeeting struct {
+	MatchID    uint
+	PlayedAt   time.Time
+	Player1Won bool
+	// Whether it was just the two of them. In doubles the points moved with the
+	// teammates too, so they don't count as exchanged between the two.
+	Singles bool
+	// Points player 1 gained (or lost when negative) in the match, and in total
+	// off player 2 in singles up to and including the match
+	Player1PointsChange    int
+	Player1PointsExchanged int
+}
+
+type Streak struct {
+	Player1 bool
+	Length  int
+}
+
+// PointsExchanged is how many points player 1 has taken off player 2 over all
+// of their singles meetings.
+func (h HeadToHead) PointsExchanged() int {
+	if len(h.Meetings) == 0 {
+		return 0
+	}
+	return h.Meetings[len(h.Meetings)-1].Player1PointsExchanged
+}
+
+// LastMeetings returns the most recent meetings, newest first.
+func (h HeadToHead) LastMeetings(n int) []HeadToHeadMeeting {
+	last := []HeadToHeadMeeting{}
+	for i := len(h.Meetings) - 1; i >= 0 && len(last) < n; i-- {
+		last = append(last, h.Meetings[i])
+	}
+	return last
+}
+
+func (g *Game) HeadToHead(player1, player2 db.User) HeadToHead {
+	h2h := HeadToHead{
+		Player1:        player1,
+		Player2:        player2,
+		Meetings:       []HeadToHeadMeeting{},
+		WinProbability: g.WinProbability([]db.User{player1}, []db.User{player2}),
+	}
+
+	pairing, ok := (*g.playerOpposingPairings)[player1.ID][player2.ID]
+	if !ok {
+		return h2h
+	}
+
+	pointsExchanged := 0
+	for _, matchId := range pairing.matches {
+		match := g.matches[matchId]
+		participant := match.Participants[player1.ID]
+
+		singles := len(match.Participants) == 2
+		pointsChange := participant.PointsAfter - participant.PointsBefore
+		if singles {
+			pointsExchanged += pointsChange
+		}
+		h2h.Meetings = append(h2h.Meetings, HeadToHeadMeeting{
+			MatchID:                matchId,
+			PlayedAt:               match.PlayedAt,
+			Player1Won:             participant.Win,
+			Singles:                singles,
+			Player1PointsChange:    pointsChange,
+			Player1PointsExchanged: pointsExchanged,
+		})
+
+		if participant.Win {
+			h2h.Player1Wins++
+		} else {
+			h2h.Player2Wins++
+		}
+
+		if h2h.CurrentStreak.Length > 0 && h2h.CurrentStreak.Player1 == participant.Win {
+			h2h.CurrentStreak.Length++
+		} else {
+			h2h.CurrentStreak = Streak{Player1: participant.Win, Length: 1}
+		}
+
+		if h2h.CurrentStreak.Player1 {
+			h2h.Player1LongestStreak = max(h2h.Player1LongestStreak, h2h.CurrentStreak.Length)
+		} else {
+			h2h.Player2LongestStreak = max(h2h.Player2LongestStreak, h2h.CurrentStreak.Length)
+		}
+	}
+
+	return h2h
+}
+
+// PointsExchangedHistory is the running total of points exchanged in singles,
+// from player 1's point of view, starting from nothing before their first
+// singles meeting.
+func (h HeadToHead) PointsExchangedHistory() []RatingPoint {
+	history := []RatingPoint{}
+	for _, meeting := range h.Meetings {
+		if !meeting.Singles {
+			continue
+		}
+
+		if len(history) == 0 {
+			history = append(history, RatingPoint{Date: meeting.PlayedAt})
+		}
+		history = append(history, RatingPoint{
+			MatchID: meeting.MatchID,
+			Date:    meeting.PlayedAt,
+			Points:  meeting.Player1PointsExchanged,
+		})
+	}
+	return history
+}
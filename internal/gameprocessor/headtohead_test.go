@@ -0,0 +1,100 @@
+package gameprocessor
+
+import (
+	"testing"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func TestHeadToHead(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []db.Match
+		// Counting from player 1's side, against player 2
+		wantWins          int
+		wantLosses        int
+		wantStreak        Streak
+		wantLongestStreak [2]int
+		// Matches counted towards the points exchanged
+		wantSingles []uint
+	}{
+		{
+			name:    "never met",
+			matches: []db.Match{testMatch(0, []uint{1}, []uint{3}, false)},
+		},
+		{
+			name: "singles",
+			matches: []db.Match{
+				testMatch(0, []uint{1}, []uint{2}, false),
+				testMatch(1, []uint{1}, []uint{2}, false),
+				testMatch(2, []uint{2}, []uint{1}, false),
+			},
+			wantWins:          2,
+			wantLosses:        1,
+			wantStreak:        Streak{Player1: false, Length: 1},
+			wantLongestStreak: [2]int{2, 1},
+			wantSingles:       []uint{1, 2, 3},
+		},
+		{
+			name: "doubles don't exchange points",
+			matches: []db.Match{
+				testMatch(0, []uint{1}, []uint{2}, false),
+				testMatch(1, []uint{2, 3}, []uint{1, 4}, false),
+				testMatch(2, []uint{2, 4}, []uint{1, 3}, false),
+			},
+			wantWins:          1,
+			wantLosses:        2,
+			wantStreak:        Streak{Player1: false, Length: 2},
+			wantLongestStreak: [2]int{1, 2},
+			wantSingles:       []uint{1},
+		},
+		{
+			name: "teammates aren't opponents",
+			matches: []db.Match{
+				testMatch(0, []uint{1, 2}, []uint{3, 4}, false),
+				testMatch(1, []uint{2}, []uint{1}, false),
+			},
+			wantLosses:        1,
+			wantStreak:        Streak{Player1: false, Length: 1},
+			wantLongestStreak: [2]int{0, 1},
+			wantSingles:       []uint{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := processTestGame(tt.matches)
+			h2h := g.HeadToHead(testUser(1), testUser(2))
+
+			if h2h.Player1Wins != tt.wantWins || h2h.Player2Wins != tt.wantLosses {
+				t.Errorf("record is %d-%d, want %d-%d", h2h.Player1Wins, h2h.Player2Wins, tt.wantWins, tt.wantLosses)
+			}
+			if h2h.CurrentStreak != tt.wantStreak {
+				t.Errorf("current streak is %+v, want %+v", h2h.CurrentStreak, tt.wantStreak)
+			}
+			if longest := [2]int{h2h.Player1LongestStreak, h2h.Player2LongestStreak}; longest != tt.wantLongestStreak {
+				t.Errorf("longest streaks are %v, want %v", longest, tt.wantLongestStreak)
+			}
+
+			wantExchanged := 0
+			for _, matchId := range tt.wantSingles {
+				wantExchanged += g.MatchParticipant(matchId, 1).PointsAfter - g.MatchParticipant(matchId, 1).PointsBefore
+			}
+			if got := h2h.PointsExchanged(); got != wantExchanged {
+				t.Errorf("points exchanged is %d, want %d", got, wantExchanged)
+			}
+
+			history := h2h.PointsExchangedHistory()
+			wantHistory := 0
+			if len(tt.wantSingles) > 0 {
+				wantHistory = len(tt.wantSingles) + 1
+			}
+			if len(history) != wantHistory {
+				t.Fatalf("points exchanged history has %d points, want %d", len(history), wantHistory)
+			}
+			if len(history) > 0 && history[len(history)-1].Points != wantExchanged {
+				t.Errorf("points exchanged history ends at %d, want %d", history[len(history)-1].Points, wantExchanged)
+			}
+		})
+	}
+}
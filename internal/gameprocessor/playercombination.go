@@ -2,6 +2,7 @@ package gameprocessor
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 )
@@ -10,23 +11,58 @@ type playerCombination struct {
 	Player1 Player
 	Player2 Player
 	matches []uint
+	// From player 1's point of view
+	WinCount  int
+	LossCount int
 }
 
 func (pc *playerCombination) MatchCount() int {
 	return len(pc.matches)
 }
 
+func (pc *playerCombination) Percentage() float64 {
+	total := pc.WinCount + pc.LossCount
+	percentage := 0.0
+	if total > 0 {
+		percentage = float64(pc.WinCount) / float64(total) * 100
+	}
+
+	return percentage
+}
+
 func (pc *playerCombination) Print() string {
+	if pc == nil {
+		return "-"
+	}
+
 	return fmt.Sprintf("%s & %s (%d)", pc.Player1.User.Username, pc.Player2.User.Username, pc.MatchCount())
 }
 
 func (pc *playerCombination) PrintOtherPlayer(p Player) string {
-	otherPlayer := pc.Player1
-	if pc.Player1.User.ID == p.User.ID {
-		otherPlayer = pc.Player2
+	if pc == nil {
+		return "-"
 	}
 
-	return fmt.Sprintf("%s (%d)", otherPlayer.User.Username, pc.MatchCount())
+	return fmt.Sprintf("%s (%d)", pc.otherPlayer(p).User.Username, pc.MatchCount())
+}
+
+func (pc *playerCombination) PrintRecord() string {
+	return fmt.Sprintf("%d-%d (%.0f%%)", pc.WinCount, pc.LossCount, pc.Percentage())
+}
+
+func (pc *playerCombination) PrintOtherPlayerRecord(p Player) string {
+	if pc == nil {
+		return "-"
+	}
+
+	return fmt.Sprintf("%s %s", pc.otherPlayer(p).User.Username, pc.PrintRecord())
+}
+
+func (pc *playerCombination) otherPlayer(p Player) Player {
+	if pc.Player1.User.ID == p.User.ID {
+		return pc.Player2
+	}
+	return pc.Player1
 }
 
 type playerCombinations map[uint]map[uint]playerCombination
@@ -35,12 +71,25 @@ func newPlayerCombinations() *playerCombinations {
 	return &playerCombinations{}
 }
 
-func (pcs *playerCombinations) addMatch(matchID uint, player1, player2 Player) {
-	pcs.addInOneDirection(matchID, player1, player2)
-	pcs.addInOneDirection(matchID, player2, player1)
+func (pcs *playerCombinations) clone() *playerCombinations {
+	clone := playerCombinations{}
+	for userId, playerMap := range *pcs {
+		clonedPlayerMap := map[uint]playerCombination{}
+		for otherUserId, pc := range playerMap {
+			pc.matches = slices.Clone(pc.matches)
+			clonedPlayerMap[otherUserId] = pc
+		}
+		clone[userId] = clonedPlayerMap
+	}
+	return &clone
+}
+
+func (pcs *playerCombinations) addMatch(matchID uint, player1, player2 Player, player1Won, player2Won bool) {
+	pcs.addInOneDirection(matchID, player1, player2, player1Won)
+	pcs.addInOneDirection(matchID, player2, player1, player2Won)
 }
 
-func (pcs *playerCombinations) addInOneDirection(matchID uint, player1, player2 Player) {
+func (pcs *playerCombinations) addInOneDirection(matchID uint, player1, player2 Player, player1Won bool) {
 	if player1.User.ID == player2.User.ID {
 		return
 	}
@@ -59,6 +108,11 @@ func (pcs *playerCombinations) addInOneDirection(matchID uint, player1, player2
 
 	pc := (*pcs)[player1.User.ID][player2.User.ID]
 	pc.matches = append((*pcs)[player1.User.ID][player2.User.ID].matches, matchID)
+	if player1Won {
+		pc.WinCount++
+	} else {
+		pc.LossCount++
+	}
 	(*pcs)[player1.User.ID][player2.User.ID] = pc
 }
 
@@ -91,6 +145,22 @@ func (pcs *playerCombinations) orderedPlayerCombinationsForUser(userId uint) []p
 	return ret
 }
 
+// rankedPlayerCombinations orders the combinations by win rate, leaving out
+// those that haven't played together enough for it to mean much.
+func (pcs *playerCombinations) rankedPlayerCombinations(minMatches int) []playerCombination {
+	ranked := []playerCombination{}
+	for _, pc := range pcs.orderedPlayerCombinations() {
+		if pc.MatchCount() >= minMatches {
+			ranked = append(ranked, pc)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Percentage() > ranked[j].Percentage()
+	})
+	return ranked
+}
+
 func print(pcs []playerCombination) string {
 	str := ""
 	for _, pc := range pcs {
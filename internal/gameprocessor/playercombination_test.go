@@ -0,0 +1,83 @@
+package gameprocessor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+var partnershipMatches = []db.Match{
+	testMatch(0, []uint{1, 2}, []uint{3, 4}, false),
+	testMatch(1, []uint{1, 2}, []uint{3, 4}, false),
+	testMatch(2, []uint{1, 3}, []uint{2, 4}, false),
+	testMatch(3, []uint{2, 4}, []uint{1, 3}, false),
+	testMatch(4, []uint{2, 4}, []uint{1, 3}, false),
+	testMatch(5, []uint{5}, []uint{1}, false),
+}
+
+func TestPartnershipRankings(t *testing.T) {
+	tests := []struct {
+		name       string
+		minMatches int
+		want       [][2]uint
+	}{
+		{name: "every pair", minMatches: 1, want: [][2]uint{{1, 2}, {2, 4}, {1, 3}, {3, 4}}},
+		{name: "enough matches together", minMatches: 3, want: [][2]uint{{2, 4}, {1, 3}}},
+		{name: "nobody has played enough", minMatches: 4, want: [][2]uint{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := processTestGame(partnershipMatches)
+
+			got := [][2]uint{}
+			for _, pc := range g.PartnershipRankings(tt.minMatches) {
+				pair := [2]uint{pc.Player1.User.ID, pc.Player2.User.ID}
+				if pair[0] > pair[1] {
+					pair[0], pair[1] = pair[1], pair[0]
+				}
+				got = append(got, pair)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBestAndWorstPartners(t *testing.T) {
+	tests := []struct {
+		name      string
+		userId    uint
+		wantBest  uint
+		wantWorst uint
+	}{
+		{name: "won most with one, lost most with another", userId: 1, wantBest: 2, wantWorst: 3},
+		{name: "from the other side", userId: 4, wantBest: 2, wantWorst: 3},
+		{name: "ties go to the partner played with most", userId: 2, wantBest: 4, wantWorst: 4},
+		{name: "only played singles", userId: 5},
+	}
+
+	partnerId := func(pc *playerCombination, userId uint) uint {
+		if pc == nil {
+			return 0
+		}
+		return pc.otherPlayer(Player{User: testUser(userId)}).User.ID
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := processTestGame(partnershipMatches)
+			player := *g.GetPlayer(tt.userId)
+
+			if got := partnerId(g.BestPartnerForPlayer(player), tt.userId); got != tt.wantBest {
+				t.Errorf("best partner is %d, want %d", got, tt.wantBest)
+			}
+			if got := partnerId(g.WorstPartnerForPlayer(player), tt.userId); got != tt.wantWorst {
+				t.Errorf("worst partner is %d, want %d", got, tt.wantWorst)
+			}
+		})
+	}
+}
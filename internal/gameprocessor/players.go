@@ -7,11 +7,19 @@ import (
 )
 
 type Player struct {
-	User      db.User
-	IsActive  bool
-	Points    int
-	WinCount  int
-	LossCount int
+	User       db.User
+	IsActive   bool
+	Points     int
+	WinCount   int
+	LossCount  int
+	LastPlayed time.Time
+
+	// Set by rating systems that need more than the rounded points
+	Rating          float64
+	RatingDeviation float64
+	Volatility      float64
+	// Whether the rating system is not yet confident in the player's rating
+	Provisional bool
 
 	RecordPoints     int
 	RecordPointsDate time.Time
@@ -0,0 +1,46 @@
+package gameprocessor
+
+import (
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+type Prediction struct {
+	Side1 []db.User
+	Side2 []db.User
+	// Side 2's chance of winning is whatever is left
+	Side1WinProbability float64
+	// Points each participant would gain, or lose when negative, keyed by user ID
+	IfSide1Wins map[uint]int
+	IfSide2Wins map[uint]int
+}
+
+// Predict works out what is at stake in a match between the two sides as things
+// stand, by rating the match both ways round without applying it.
+func (g *Game) Predict(side1, side2 []db.User, isHandicap bool) Prediction {
+	players1 := g.currentPlayers(side1)
+	players2 := g.currentPlayers(side2)
+
+	prediction := Prediction{
+		Side1:               side1,
+		Side2:               side2,
+		Side1WinProbability: g.ratingSystem.WinProbability(players1, players2),
+		IfSide1Wins:         pointsChanges(g.ratingSystem, isHandicap, players1, players2),
+		IfSide2Wins:         pointsChanges(g.ratingSystem, isHandicap, players2, players1),
+	}
+	if isHandicap {
+		// Handicaps are there to even the match up
+		prediction.Side1WinProbability = 0.5
+	}
+
+	return prediction
+}
+
+func pointsChanges(ratingSystem RatingSystem, isHandicap bool, winners, losers []Player) map[uint]int {
+	rated := ratingSystem.RateMatch(db.Match{IsHandicap: isHandicap}, winners, losers)
+
+	changes := map[uint]int{}
+	for _, player := range append(winners, losers...) {
+		changes[player.User.ID] = rated[player.User.ID].Points - player.Points
+	}
+	return changes
+}
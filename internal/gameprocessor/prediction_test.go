@@ -0,0 +1,96 @@
+package gameprocessor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func TestPredict(t *testing.T) {
+	history := []db.Match{
+		testMatch(0, []uint{1}, []uint{2}, false),
+		testMatch(1, []uint{1}, []uint{3}, false),
+		testMatch(2, []uint{3}, []uint{2}, false),
+	}
+
+	tests := []struct {
+		name       string
+		side1      []uint
+		side2      []uint
+		isHandicap bool
+		// Whether side 1 should be favourite, or it's even
+		wantFavourite bool
+		wantEven      bool
+	}{
+		{name: "new players", side1: []uint{4}, side2: []uint{5}, wantEven: true},
+		{name: "favourite", side1: []uint{1}, side2: []uint{2}, wantFavourite: true},
+		{name: "underdog", side1: []uint{2}, side2: []uint{1}},
+		{name: "handicap", side1: []uint{1}, side2: []uint{2}, isHandicap: true, wantEven: true},
+		{name: "doubles", side1: []uint{1, 3}, side2: []uint{2, 4}, wantFavourite: true},
+	}
+
+	ratingSystems := []string{db.RatingSystemElo, db.RatingSystemGlicko, db.RatingSystemTrueSkill}
+
+	for _, tt := range tests {
+		for _, ratingSystem := range ratingSystems {
+			t.Run(tt.name+"/"+ratingSystem, func(t *testing.T) {
+				game := db.Game{RatingSystem: ratingSystem, RatingSettings: defaultRatingSettings}
+				g := newGame(newRatingSystem(game), defaultRatingSettings)
+				for _, match := range history {
+					g.applyMatch(match)
+				}
+
+				// Predicting for a month on, so ratings that drift while nobody
+				// plays have had time to
+				g.asOf = testStart.AddDate(0, 1, 0)
+
+				users := func(ids []uint) []db.User {
+					users := []db.User{}
+					for _, id := range ids {
+						users = append(users, testUser(id))
+					}
+					return users
+				}
+
+				prediction := g.Predict(users(tt.side1), users(tt.side2), tt.isHandicap)
+
+				probability := prediction.Side1WinProbability
+				switch {
+				case tt.wantEven && math.Abs(probability-0.5) > 0.0001:
+					t.Errorf("side 1 win probability is %.3f, want even", probability)
+				case !tt.wantEven && tt.wantFavourite && probability <= 0.5:
+					t.Errorf("side 1 win probability is %.3f, want favourite", probability)
+				case !tt.wantEven && !tt.wantFavourite && probability >= 0.5:
+					t.Errorf("side 1 win probability is %.3f, want underdog", probability)
+				}
+
+				// The points at stake are what the match would actually do
+				outcomes := []struct {
+					winners, losers []uint
+					want            map[uint]int
+				}{
+					{winners: tt.side1, losers: tt.side2, want: prediction.IfSide1Wins},
+					{winners: tt.side2, losers: tt.side1, want: prediction.IfSide2Wins},
+				}
+				for _, outcome := range outcomes {
+					played := g.clone()
+					match := testMatch(len(history), outcome.winners, outcome.losers, tt.isHandicap)
+					match.PlayedAt = g.asOf
+					played.applyMatch(match)
+
+					for userId, want := range outcome.want {
+						participant := played.MatchParticipant(match.ID, userId)
+						if got := participant.PointsAfter - participant.PointsBefore; got != want {
+							t.Errorf("user %d moved %d points when %v won, predicted %d", userId, got, outcome.winners, want)
+						}
+					}
+				}
+
+				if g.MatchesPlayed() != len(history) {
+					t.Errorf("predicting played a match")
+				}
+			})
+		}
+	}
+}
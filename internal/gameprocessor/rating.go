@@ -0,0 +1,42 @@
+package gameprocessor
+
+import (
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+// RatingSystem is the engine used to rate each match as the game processor
+// replays an office's history. Implementations only deal with ratings, the
+// processor takes care of win/loss counts, records and pairings.
+type RatingSystem interface {
+	// NewPlayer returns the player as they should be before their first match.
+	NewPlayer(user db.User) Player
+	// RateMatch takes the participants as they were before the match and returns
+	// them with their ratings updated, keyed by user ID.
+	RateMatch(match db.Match, winners, losers []Player) map[uint]Player
+	// WinProbability is the chance of the team beating the opponents given their
+	// current ratings.
+	WinProbability(team, opponents []Player) float64
+}
+
+// inactivityDecayer is implemented by rating systems where a player's rating
+// changes while they aren't playing.
+type inactivityDecayer interface {
+	Decay(player Player, at time.Time) Player
+}
+
+func newRatingSystem(game db.Game) RatingSystem {
+	settings := game.RatingSettings
+	switch game.RatingSystem {
+	case db.RatingSystemElo:
+		return eloRatingSystem{settings: settings}
+	case db.RatingSystemGlicko:
+		return glickoRatingSystem{settings: settings}
+	case db.RatingSystemTrueSkill:
+		return newTrueSkillRatingSystem(settings)
+	}
+
+	// Fall back to Elo for anything unrecognised
+	return eloRatingSystem{settings: settings}
+}
@@ -0,0 +1,197 @@
+package gameprocessor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"gorm.io/gorm/clause"
+)
+
+const snapshotBatchSize = 500
+
+type snapshotKey struct {
+	matchId uint
+	userId  uint
+}
+
+// saveSnapshots brings the game's rating snapshots in line with the processed
+// game. Only snapshots that are missing or no longer agree with it are written,
+// and the ones they replace are marked as superseded rather than changed. With
+// matchIds set only those matches are looked at, otherwise the whole game is.
+func (gp *GameProcessor) saveSnapshots(officeId, gameId uint, g *Game, matchIds []uint) {
+	query := gp.db.C.Joins("Match").
+		Where("rating_snapshots.game_id = ? AND rating_snapshots.superseded_at IS NULL", gameId)
+	if matchIds != nil {
+		query = query.Where("rating_snapshots.match_id IN ?", matchIds)
+	}
+
+	current := []db.RatingSnapshot{}
+	err := query.Find(&current).Error
+	if err != nil {
+		fmt.Printf("Error loading rating snapshots for game %d: %v\n", gameId, err)
+		return
+	}
+
+	superseded, snapshots := snapshotChanges(officeId, gameId, g, current, matchIds)
+
+	if len(superseded) == 0 && len(snapshots) == 0 {
+		return
+	}
+
+	// The snapshots can always be rebuilt with a replay, so failures are only logged
+	tx := gp.db.C.Begin()
+
+	now := time.Now()
+	for start := 0; start < len(superseded); start += snapshotBatchSize {
+		end := min(start+snapshotBatchSize, len(superseded))
+		err = tx.Model(&db.RatingSnapshot{}).Where("id IN ?", superseded[start:end]).Update("superseded_at", now).Error
+		if err != nil {
+			tx.Rollback()
+			fmt.Printf("Error superseding rating snapshots for game %d: %v\n", gameId, err)
+			return
+		}
+	}
+
+	if len(snapshots) > 0 {
+		// Another replay may have got there first
+		err = tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "match_id"}, {Name: "user_id"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "superseded_at IS NULL"}}},
+			DoNothing:   true,
+		}).CreateInBatches(&snapshots, snapshotBatchSize).Error
+		if err != nil {
+			tx.Rollback()
+			fmt.Printf("Error saving rating snapshots for game %d: %v\n", gameId, err)
+			return
+		}
+	}
+
+	err = tx.Commit().Error
+	if err != nil {
+		fmt.Printf("Error saving rating snapshots for game %d: %v\n", gameId, err)
+	}
+}
+
+// snapshotChanges works out which of the current snapshots no longer agree with
+// the processed game, and the snapshots that need saving in their place.
+func snapshotChanges(officeId, gameId uint, g *Game, current []db.RatingSnapshot, matchIds []uint) ([]uint, []db.RatingSnapshot) {
+	upToDate := map[snapshotKey]bool{}
+	superseded := []uint{}
+	for _, snapshot := range current {
+		participant := g.MatchParticipant(snapshot.MatchID, snapshot.UserID)
+		if participant == nil {
+			// A match approved since the game was processed isn't in it yet, so
+			// keeps its snapshots until it is
+			if g.GetMatch(snapshot.MatchID) == nil && snapshot.Match.State == db.MatchStateApproved {
+				continue
+			}
+
+			superseded = append(superseded, snapshot.ID)
+			continue
+		}
+		if participant.PointsBefore != snapshot.PointsBefore || participant.PointsAfter != snapshot.PointsAfter {
+			superseded = append(superseded, snapshot.ID)
+			continue
+		}
+
+		upToDate[snapshotKey{matchId: snapshot.MatchID, userId: snapshot.UserID}] = true
+	}
+
+	if matchIds == nil {
+		for matchId := range g.matches {
+			matchIds = append(matchIds, matchId)
+		}
+	}
+
+	snapshots := []db.RatingSnapshot{}
+	for _, matchId := range matchIds {
+		match := g.GetMatch(matchId)
+		if match == nil {
+			continue
+		}
+
+		for _, participant := range match.Participants {
+			if upToDate[snapshotKey{matchId: matchId, userId: participant.UserID}] {
+				continue
+			}
+
+			snapshots = append(snapshots, db.RatingSnapshot{
+				OfficeID:     officeId,
+				GameID:       gameId,
+				MatchID:      matchId,
+				UserID:       participant.UserID,
+				PointsBefore: participant.PointsBefore,
+				PointsAfter:  participant.PointsAfter,
+			})
+		}
+	}
+
+	return superseded, snapshots
+}
+
+// MatchPoints is how many points each participant of a match won or lost.
+type MatchPoints interface {
+	MatchParticipant(matchId, userId uint) *ProcessedMatchParticipant
+}
+
+type snapshotPoints map[snapshotKey]*ProcessedMatchParticipant
+
+// NewSnapshotPoints gives the points of the matches as they were recorded in
+// their rating snapshots, without processing the game.
+func NewSnapshotPoints(matches []db.Match, snapshots []db.RatingSnapshot) MatchPoints {
+	wins := map[snapshotKey]bool{}
+	for _, match := range matches {
+		for _, participant := range match.Participants {
+			wins[snapshotKey{matchId: match.ID, userId: participant.UserID}] = participant.Result == "win"
+		}
+	}
+
+	points := snapshotPoints{}
+	for _, snapshot := range snapshots {
+		key := snapshotKey{matchId: snapshot.MatchID, userId: snapshot.UserID}
+		win, ok := wins[key]
+		if !ok {
+			continue
+		}
+
+		applied := snapshot.PointsApplied()
+		if !win {
+			applied = -applied
+		}
+
+		points[key] = &ProcessedMatchParticipant{
+			UserID:        snapshot.UserID,
+			Win:           win,
+			PointsApplied: applied,
+			PointsBefore:  snapshot.PointsBefore,
+			PointsAfter:   snapshot.PointsAfter,
+		}
+	}
+
+	return points
+}
+
+func (sp snapshotPoints) MatchParticipant(matchId, userId uint) *ProcessedMatchParticipant {
+	return sp[snapshotKey{matchId: matchId, userId: userId}]
+}
+
+// SnapshotRatingHistory is the player's points after each of the matches
+// snapshotted, starting with the points they had before the first. The
+// snapshots must be the player's, in the order the matches were played.
+func SnapshotRatingHistory(snapshots []db.RatingSnapshot) []RatingPoint {
+	history := []RatingPoint{}
+	for _, snapshot := range snapshots {
+		if len(history) == 0 {
+			history = append(history, RatingPoint{Date: snapshot.Match.PlayedAt, Points: snapshot.PointsBefore})
+		}
+
+		history = append(history, RatingPoint{
+			MatchID: snapshot.MatchID,
+			Date:    snapshot.Match.PlayedAt,
+			Points:  snapshot.PointsAfter,
+		})
+	}
+
+	return history
+}
@@ -0,0 +1,188 @@
+package gameprocessor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func processTestGame(matches []db.Match) *Game {
+	g := newGame(eloRatingSystem{settings: defaultRatingSettings}, defaultRatingSettings)
+	for _, match := range matches {
+		g.applyMatch(match)
+	}
+	return &g
+}
+
+// saveTestSnapshots does what saveSnapshots does to the current snapshots, with
+// the matches as they now are, and returns the ones left current.
+func saveTestSnapshots(g *Game, current []db.RatingSnapshot, matches []db.Match) []db.RatingSnapshot {
+	states := map[uint]string{}
+	for _, match := range matches {
+		states[match.ID] = match.State
+	}
+	for i := range current {
+		current[i].Match.State = states[current[i].MatchID]
+	}
+
+	superseded, snapshots := snapshotChanges(1, 1, g, current, nil)
+
+	saved := []db.RatingSnapshot{}
+	for _, snapshot := range current {
+		if !slices.Contains(superseded, snapshot.ID) {
+			saved = append(saved, snapshot)
+		}
+	}
+	for i, snapshot := range snapshots {
+		snapshot.ID = uint(len(current) + i + 1)
+		saved = append(saved, snapshot)
+	}
+	return saved
+}
+
+func TestSnapshotChanges(t *testing.T) {
+	doubles := testMatch(0, []uint{1, 2}, []uint{3, 4}, false)
+	singles := testMatch(1, []uint{1}, []uint{3}, false)
+
+	editedDoubles := testMatch(0, []uint{1, 5}, []uint{3, 4}, false)
+
+	voidedSingles := singles
+	voidedSingles.State = db.MatchStateVoided
+
+	tests := []struct {
+		name string
+		// The matches as first processed, and as they are when processed again
+		before []db.Match
+		after  []db.Match
+		// The matches the game is processed from the second time, if not all of after
+		processed []db.Match
+		want      map[uint][]uint
+	}{
+		{
+			name:   "nothing changed",
+			before: []db.Match{doubles, singles},
+			after:  []db.Match{doubles, singles},
+			want:   map[uint][]uint{1: {1, 2, 3, 4}, 2: {1, 3}},
+		},
+		{
+			name:   "participant removed from an approved match",
+			before: []db.Match{doubles, singles},
+			after:  []db.Match{editedDoubles, singles},
+			want:   map[uint][]uint{1: {1, 3, 4, 5}, 2: {1, 3}},
+		},
+		{
+			name:   "match voided",
+			before: []db.Match{doubles, singles},
+			after:  []db.Match{doubles, voidedSingles},
+			want:   map[uint][]uint{1: {1, 2, 3, 4}},
+		},
+		{
+			name:      "match approved since the game was processed",
+			before:    []db.Match{doubles, singles},
+			after:     []db.Match{doubles, singles},
+			processed: []db.Match{doubles},
+			want:      map[uint][]uint{1: {1, 2, 3, 4}, 2: {1, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := saveTestSnapshots(processTestGame(tt.before), nil, tt.before)
+
+			processed := tt.processed
+			if processed == nil {
+				for _, match := range tt.after {
+					if match.State == db.MatchStateApproved {
+						processed = append(processed, match)
+					}
+				}
+			}
+			g := processTestGame(processed)
+			saved := saveTestSnapshots(g, current, tt.after)
+
+			got := map[uint][]uint{}
+			for _, snapshot := range saved {
+				got[snapshot.MatchID] = append(got[snapshot.MatchID], snapshot.UserID)
+
+				participant := g.MatchParticipant(snapshot.MatchID, snapshot.UserID)
+				if participant != nil && (participant.PointsBefore != snapshot.PointsBefore || participant.PointsAfter != snapshot.PointsAfter) {
+					t.Errorf("snapshot for match %d user %d is %d to %d, want %d to %d", snapshot.MatchID, snapshot.UserID, snapshot.PointsBefore, snapshot.PointsAfter, participant.PointsBefore, participant.PointsAfter)
+				}
+			}
+			for matchId := range got {
+				slices.Sort(got[matchId])
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("current snapshots are %v, want %v", got, tt.want)
+			}
+			for matchId, want := range tt.want {
+				if !slices.Equal(got[matchId], want) {
+					t.Errorf("match %d has current snapshots for %v, want %v", matchId, got[matchId], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotsAgreeWithProcessing(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []db.Match
+	}{
+		{
+			name:    "singles",
+			matches: []db.Match{testMatch(0, []uint{1}, []uint{2}, false), testMatch(1, []uint{2}, []uint{1}, false)},
+		},
+		{
+			name: "doubles and handicap",
+			matches: []db.Match{
+				testMatch(0, []uint{1, 2}, []uint{3, 4}, false),
+				testMatch(1, []uint{3}, []uint{1}, true),
+				testMatch(2, []uint{1, 4}, []uint{2, 3}, false),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := processTestGame(tt.matches)
+			_, snapshots := snapshotChanges(1, 1, g, nil, nil)
+
+			matches := map[uint]db.Match{}
+			for _, match := range tt.matches {
+				matches[match.ID] = match
+			}
+			for i := range snapshots {
+				snapshots[i].Match = matches[snapshots[i].MatchID]
+			}
+			slices.SortFunc(snapshots, func(a, b db.RatingSnapshot) int {
+				return int(a.MatchID) - int(b.MatchID)
+			})
+
+			points := NewSnapshotPoints(tt.matches, snapshots)
+			for _, match := range tt.matches {
+				for _, participant := range match.Participants {
+					got := points.MatchParticipant(match.ID, participant.UserID)
+					want := g.MatchParticipant(match.ID, participant.UserID)
+					if got == nil || *got != *want {
+						t.Errorf("match %d user %d has points %+v, want %+v", match.ID, participant.UserID, got, want)
+					}
+				}
+			}
+
+			users := map[uint][]db.RatingSnapshot{}
+			for _, snapshot := range snapshots {
+				users[snapshot.UserID] = append(users[snapshot.UserID], snapshot)
+			}
+			for userId, userSnapshots := range users {
+				got := SnapshotRatingHistory(userSnapshots)
+				want := g.RatingHistory(userId)
+				if !slices.Equal(got, want) {
+					t.Errorf("user %d has history %v, want %v", userId, got, want)
+				}
+			}
+		})
+	}
+}
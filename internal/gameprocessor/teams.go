@@ -0,0 +1,127 @@
+package gameprocessor
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+type TeamSplit struct {
+	Team1 []db.User
+	Team2 []db.User
+	// Left over when there's an odd number of players
+	SittingOut          []db.User
+	Team1WinProbability float64
+	// Teammates in the split that have partnered each other recently
+	RecentPairings int
+}
+
+// Imbalance is how far the split is from an even match, 0 being a coin toss.
+func (ts TeamSplit) Imbalance() float64 {
+	return math.Abs(ts.Team1WinProbability - 0.5)
+}
+
+// BalancedTeams splits the players into two even teams every way it can, most
+// balanced first. When recentSince is set, splits that reunite teammates who
+// have partnered since then go to the back.
+func (g *Game) BalancedTeams(users []db.User, recentSince time.Time) []TeamSplit {
+	splits := []TeamSplit{}
+
+	sittingOutOptions := [][]db.User{{}}
+	if len(users)%2 == 1 {
+		sittingOutOptions = [][]db.User{}
+		for _, user := range users {
+			sittingOutOptions = append(sittingOutOptions, []db.User{user})
+		}
+	}
+
+	for _, sittingOut := range sittingOutOptions {
+		playing := []db.User{}
+		for _, user := range users {
+			if len(sittingOut) == 0 || user.ID != sittingOut[0].ID {
+				playing = append(playing, user)
+			}
+		}
+
+		for _, team1 := range teamsIncludingFirst(playing, len(playing)/2) {
+			team2 := []db.User{}
+			for _, user := range playing {
+				if !containsUser(team1, user) {
+					team2 = append(team2, user)
+				}
+			}
+
+			split := TeamSplit{
+				Team1:               team1,
+				Team2:               team2,
+				SittingOut:          sittingOut,
+				Team1WinProbability: g.ratingSystem.WinProbability(g.currentPlayers(team1), g.currentPlayers(team2)),
+			}
+			if !recentSince.IsZero() {
+				split.RecentPairings = g.recentPairings(team1, recentSince) + g.recentPairings(team2, recentSince)
+			}
+			splits = append(splits, split)
+		}
+	}
+
+	sort.SliceStable(splits, func(i, j int) bool {
+		if splits[i].RecentPairings != splits[j].RecentPairings {
+			return splits[i].RecentPairings < splits[j].RecentPairings
+		}
+		return splits[i].Imbalance() < splits[j].Imbalance()
+	})
+
+	return splits
+}
+
+// teamsIncludingFirst returns every team of the given size that includes the
+// first player, so each split is only found once rather than also mirrored.
+func teamsIncludingFirst(players []db.User, size int) [][]db.User {
+	if len(players) == 0 || size == 0 {
+		return [][]db.User{}
+	}
+
+	teams := [][]db.User{}
+	var build func(team []db.User, next int)
+	build = func(team []db.User, next int) {
+		if len(team) == size {
+			teams = append(teams, append([]db.User{}, team...))
+			return
+		}
+		for i := next; i < len(players); i++ {
+			build(append(team, players[i]), i+1)
+		}
+	}
+	build([]db.User{players[0]}, 1)
+
+	return teams
+}
+
+func (g *Game) recentPairings(team []db.User, since time.Time) int {
+	count := 0
+	for i, user := range team {
+		for _, teammate := range team[i+1:] {
+			pairing, ok := (*g.playerPairings)[user.ID][teammate.ID]
+			if !ok || len(pairing.matches) == 0 {
+				continue
+			}
+
+			lastMatch := g.matches[pairing.matches[len(pairing.matches)-1]]
+			if lastMatch.PlayedAt.After(since) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func containsUser(users []db.User, user db.User) bool {
+	for _, u := range users {
+		if u.ID == user.ID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package gameprocessor
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func teamIds(team []db.User) []uint {
+	ids := []uint{}
+	for _, user := range team {
+		ids = append(ids, user.ID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestBalancedTeams(t *testing.T) {
+	tests := []struct {
+		name   string
+		points map[uint]int
+		// Teammates that partnered each other in a match just now
+		recentTeammates []uint
+		wantSplits      int
+		wantBest        [][]uint
+		wantSittingOut  bool
+	}{
+		{
+			name:       "two players",
+			points:     map[uint]int{1: 400, 2: 500},
+			wantSplits: 1,
+			wantBest:   [][]uint{{1}, {2}},
+		},
+		{
+			name:       "strongest with weakest",
+			points:     map[uint]int{1: 600, 2: 500, 3: 400, 4: 300},
+			wantSplits: 3,
+			wantBest:   [][]uint{{1, 4}, {2, 3}},
+		},
+		{
+			name:           "odd one out",
+			points:         map[uint]int{1: 600, 2: 500, 3: 400, 4: 300, 5: 450},
+			wantSplits:     15,
+			wantBest:       [][]uint{{1, 4}, {2, 3}},
+			wantSittingOut: true,
+		},
+		{
+			name:            "recent teammates split up",
+			points:          map[uint]int{1: 600, 2: 500, 3: 400, 4: 300},
+			recentTeammates: []uint{1, 4},
+			wantSplits:      3,
+			wantBest:        [][]uint{{1, 3}, {2, 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGame(eloRatingSystem{settings: defaultRatingSettings}, defaultRatingSettings)
+			if len(tt.recentTeammates) > 0 {
+				g.applyMatch(testMatch(0, tt.recentTeammates, []uint{2, 3}, false))
+			}
+
+			users := []db.User{}
+			for id := range uint(len(tt.points)) {
+				user := testUser(id + 1)
+				users = append(users, user)
+				g.players[user.ID] = Player{User: user, Points: tt.points[user.ID]}
+			}
+
+			recentSince := time.Time{}
+			if len(tt.recentTeammates) > 0 {
+				recentSince = testStart.Add(-time.Hour)
+			}
+
+			splits := g.BalancedTeams(users, recentSince)
+			if len(splits) != tt.wantSplits {
+				t.Fatalf("got %d splits, want %d", len(splits), tt.wantSplits)
+			}
+
+			best := splits[0]
+			got := [][]uint{teamIds(best.Team1), teamIds(best.Team2)}
+			slices.SortFunc(got, slices.Compare)
+			if !slices.EqualFunc(got, tt.wantBest, slices.Equal) {
+				t.Errorf("best split is %v, want %v", got, tt.wantBest)
+			}
+			if (len(best.SittingOut) > 0) != tt.wantSittingOut {
+				t.Errorf("best split has %v sitting out", teamIds(best.SittingOut))
+			}
+
+			for i := 1; i < len(splits); i++ {
+				if splits[i].RecentPairings == splits[i-1].RecentPairings && splits[i].Imbalance() < splits[i-1].Imbalance() {
+					t.Errorf("split %d is more balanced than the one before it", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package gameprocessor
+
+import (
+	"math"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+// TrueSkill for two teams without draws, see
+// https://www.microsoft.com/en-us/research/publication/trueskilltm-a-bayesian-skill-rating-system/
+// A team's performance is the sum of its players' performances, so the bigger
+// side of an uneven match is expected to win, and the update is shared out by
+// each player's uncertainty.
+// Everything is kept on the points scale so no conversion is needed for display.
+type trueSkillRatingSystem struct {
+	startingMu       float64
+	startingSigma    float64
+	beta             float64
+	dynamics         float64
+	provisionalSigma float64
+}
+
+func newTrueSkillRatingSystem(settings db.RatingSettings) trueSkillRatingSystem {
+	startingMu := float64(settings.StartingPoints)
+	startingSigma := startingMu / 3
+
+	return trueSkillRatingSystem{
+		startingMu:       startingMu,
+		startingSigma:    startingSigma,
+		beta:             startingSigma / 2,
+		dynamics:         startingSigma / 100,
+		provisionalSigma: startingSigma / 2,
+	}
+}
+
+func (ts trueSkillRatingSystem) NewPlayer(user db.User) Player {
+	return Player{
+		User:            user,
+		Points:          int(math.Round(ts.startingMu)),
+		Rating:          ts.startingMu,
+		RatingDeviation: ts.startingSigma,
+		Provisional:     true,
+	}
+}
+
+func (ts trueSkillRatingSystem) RateMatch(match db.Match, winners, losers []Player) map[uint]Player {
+	winnersMu, losersMu, c := ts.performance(winners, losers)
+
+	t := (winnersMu - losersMu) / c
+	if match.IsHandicap {
+		// Handicaps are there to even the match up
+		t = 0
+	}
+	v := gaussianPDF(t) / gaussianCDF(t)
+	w := v * (v + t)
+
+	rated := map[uint]Player{}
+	for _, winner := range winners {
+		rated[winner.User.ID] = ts.update(winner, c, v, w)
+	}
+	for _, loser := range losers {
+		rated[loser.User.ID] = ts.update(loser, c, -v, w)
+	}
+
+	return rated
+}
+
+func (ts trueSkillRatingSystem) WinProbability(team, opponents []Player) float64 {
+	teamMu, opponentsMu, c := ts.performance(team, opponents)
+	return gaussianCDF((teamMu - opponentsMu) / c)
+}
+
+// performance returns each team's summed skill and the spread of the
+// difference in their performances.
+func (ts trueSkillRatingSystem) performance(team, opponents []Player) (teamMu, opponentsMu, c float64) {
+	cSquared := float64(0)
+	for _, player := range team {
+		cSquared += ts.performanceVariance(player)
+		teamMu += player.Rating
+	}
+	for _, player := range opponents {
+		cSquared += ts.performanceVariance(player)
+		opponentsMu += player.Rating
+	}
+
+	return teamMu, opponentsMu, math.Sqrt(cSquared)
+}
+
+func (ts trueSkillRatingSystem) sigmaSquared(player Player) float64 {
+	return player.RatingDeviation*player.RatingDeviation + ts.dynamics*ts.dynamics
+}
+
+func (ts trueSkillRatingSystem) performanceVariance(player Player) float64 {
+	return ts.sigmaSquared(player) + ts.beta*ts.beta
+}
+
+func (ts trueSkillRatingSystem) update(player Player, c, v, w float64) Player {
+	sigmaSquared := ts.sigmaSquared(player)
+
+	player.Rating += sigmaSquared / c * v
+	player.RatingDeviation = math.Sqrt(sigmaSquared * math.Max(1-sigmaSquared/(c*c)*w, 0))
+	player.Points = int(math.Round(player.Rating))
+	player.Provisional = player.RatingDeviation > ts.provisionalSigma
+	return player
+}
+
+func gaussianPDF(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
+
+func gaussianCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
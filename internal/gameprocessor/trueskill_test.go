@@ -0,0 +1,83 @@
+package gameprocessor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+)
+
+func freshTrueSkillPlayers(ts trueSkillRatingSystem, ids ...uint) []Player {
+	players := []Player{}
+	for _, player := range testPlayers(ids...) {
+		players = append(players, ts.NewPlayer(player.User))
+	}
+	return players
+}
+
+func TestTrueSkillWinProbability(t *testing.T) {
+	ts := newTrueSkillRatingSystem(defaultRatingSettings)
+
+	tests := []struct {
+		name      string
+		team      []uint
+		opponents []uint
+		want      func(float64) bool
+	}{
+		{
+			name:      "1v1 is even",
+			team:      []uint{1},
+			opponents: []uint{2},
+			want:      func(p float64) bool { return math.Abs(p-0.5) < 1e-9 },
+		},
+		{
+			name:      "2v2 is even",
+			team:      []uint{1, 2},
+			opponents: []uint{3, 4},
+			want:      func(p float64) bool { return math.Abs(p-0.5) < 1e-9 },
+		},
+		{
+			name:      "2v1 favours the pair",
+			team:      []uint{1, 2},
+			opponents: []uint{3},
+			want:      func(p float64) bool { return p > 0.5 },
+		},
+		{
+			name:      "1v2 favours the pair",
+			team:      []uint{1},
+			opponents: []uint{2, 3},
+			want:      func(p float64) bool { return p < 0.5 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ts.WinProbability(freshTrueSkillPlayers(ts, tt.team...), freshTrueSkillPlayers(ts, tt.opponents...))
+			if !tt.want(got) {
+				t.Errorf("WinProbability() = %.4f", got)
+			}
+		})
+	}
+}
+
+func TestTrueSkillUnevenTeams(t *testing.T) {
+	ts := newTrueSkillRatingSystem(defaultRatingSettings)
+	pair := freshTrueSkillPlayers(ts, 1, 2)
+	single := freshTrueSkillPlayers(ts, 3)
+
+	pairWon := ts.RateMatch(db.Match{}, pair, single)
+	singleWon := ts.RateMatch(db.Match{}, single, pair)
+
+	pairGain := pairWon[1].Rating - pair[0].Rating
+	singleGain := singleWon[3].Rating - single[0].Rating
+	if pairGain <= 0 || singleGain <= 0 {
+		t.Fatalf("winners should gain, pair gained %.2f and single gained %.2f", pairGain, singleGain)
+	}
+	if singleGain <= pairGain {
+		t.Errorf("an upset should be worth more, single gained %.2f and pair gained %.2f", singleGain, pairGain)
+	}
+
+	if pairWon[1].Rating != pairWon[2].Rating {
+		t.Errorf("players as uncertain as each other should move the same, got %.2f and %.2f", pairWon[1].Rating, pairWon[2].Rating)
+	}
+}
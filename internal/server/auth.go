@@ -113,6 +113,24 @@ func (s *Server) enforceMember(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+func (s *Server) enforceAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user := userFromContext(c)
+		if user == nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		}
+
+		officeCode := c.Param("code")
+		for _, o := range user.Offices {
+			if o.Code == officeCode && o.AdminRefer == user.ID {
+				return next(c)
+			}
+		}
+
+		return echo.NewHTTPError(http.StatusForbidden, "You are not the admin of this office")
+	}
+}
+
 func sendForgotPasswordEmail(c echo.Context, user *db.User) error {
 	token, err := token.GenerateToken(user.ID, token.ForgotPasswordToken)
 	if err != nil {
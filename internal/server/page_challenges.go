@@ -0,0 +1,166 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/app"
+	"github.com/RowMur/office-table-tennis/internal/db"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) challengesPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	challenges, err := s.app.GetOutstandingGameChallenges(game)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.ChallengesPage(*office, *game, challenges, user))
+}
+
+func (s *Server) createChallengeHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	formData := officeViews.ChallengeFormData{
+		Game:     c.FormValue("game"),
+		Opponent: c.FormValue("opponent"),
+		Deadline: c.FormValue("deadline"),
+		Note:     c.FormValue("note"),
+	}
+
+	var deadline *time.Time
+	if formData.Deadline != "" {
+		date, err := time.Parse(time.DateOnly, formData.Deadline)
+		if err != nil {
+			errs := officeViews.ChallengeFormErrors{Challenge: "Invalid deadline"}
+			return render(c, http.StatusOK, officeViews.ChallengeForm(*office, user, formData, errs))
+		}
+
+		// Give them until the end of the day
+		date = date.Add(24*time.Hour - time.Second)
+		deadline = &date
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	userErr, err := s.app.CreateChallenge(user, office, game, formData.Opponent, deadline, formData.Note)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	if userErr != nil {
+		errs := officeViews.ChallengeFormErrors{Challenge: userErr.Error()}
+		return render(c, http.StatusOK, officeViews.ChallengeForm(*office, user, formData, errs))
+	}
+
+	c.Response().Header().Set("HX-Refresh", "true")
+	return c.NoContent(http.StatusOK)
+}
+
+// challengeFromRequest loads the office and challenge in the path.
+func (s *Server) challengeFromRequest(c echo.Context) (*db.Office, *db.Challenge, error) {
+	office, err := s.app.GetOfficeByCode(c.Param("code"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	challenge, err := s.app.GetChallengeById(office.ID, c.Param("challengeId"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if challenge == nil {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, "Challenge not found")
+	}
+
+	return office, challenge, nil
+}
+
+func (s *Server) challengePageHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, challenge, err := s.challengeFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	return render(c, http.StatusOK, officeViews.ChallengePage(*office, *challenge, user))
+}
+
+func (s *Server) challengeAcceptHandler(c echo.Context) error {
+	return s.respondToChallenge(c, true)
+}
+
+func (s *Server) challengeDeclineHandler(c echo.Context) error {
+	return s.respondToChallenge(c, false)
+}
+
+func (s *Server) respondToChallenge(c echo.Context, accept bool) error {
+	user := userFromContext(c)
+
+	_, challenge, err := s.challengeFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	err = s.app.RespondToChallenge(user, challenge, accept)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	c.Response().Header().Set("HX-Refresh", "true")
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) challengeResultHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, challenge, err := s.challengeFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	bestOf, _ := strconv.Atoi(c.FormValue("bestOf"))
+	scores, err := app.ParseMatchScores(c.FormValue("scores"), bestOf, challenge.Game)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	match, err := s.app.LogChallengeMatch(user, office, challenge, c.FormValue("winner"), bestOf, scores)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	// Not the end of the world if the auto approve doesnt work
+	_ = s.app.ApproveMatch(user, match)
+	if match.IsApproved() {
+		c.Response().Header().Set("HX-Refresh", "true")
+		return c.NoContent(http.StatusOK)
+	}
+
+	c.Response().Header().Set("HX-Redirect", office.Link()+fmt.Sprintf("/pending/%d", match.ID))
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+
+	"github.com/RowMur/office-table-tennis/internal/app"
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"github.com/RowMur/office-table-tennis/internal/email"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+// disputedMatchFromRequest loads the office and the disputed match in the path.
+func (s *Server) disputedMatchFromRequest(c echo.Context) (*db.Office, *db.Match, error) {
+	office, err := s.app.GetOfficeByCode(c.Param("code"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	match, err := s.app.GetMatchById(c.Param("matchId"))
+	if err != nil {
+		if db.IsRecordNotFoundError(err) {
+			return nil, nil, echo.NewHTTPError(http.StatusNotFound, "Match not found")
+		}
+
+		return nil, nil, err
+	}
+	if match.OfficeID != office.ID || match.State != db.MatchStateDisputed {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, "Match not found")
+	}
+
+	return office, match, nil
+}
+
+func (s *Server) disputedMatchesPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, err := s.app.GetOfficeByCode(c.Param("code"))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	matches, err := s.app.GetDisputedMatches(game)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.DisputedMatchesPage(*office, *game, matches, user))
+}
+
+func (s *Server) disputedMatchPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, match, err := s.disputedMatchFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	return render(c, http.StatusOK, officeViews.DisputedMatchPage(*office, *match, user))
+}
+
+func (s *Server) pendingMatchDisputeHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	match, err := s.app.GetMatchById(c.Param("matchId"))
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchApproveError(err.Error()))
+	}
+
+	userErr, err := s.app.DisputeMatch(user, match, c.FormValue("reason"))
+	if userErr != nil {
+		return render(c, http.StatusOK, officeViews.MatchApproveError(userErr.Error()))
+	}
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	// The dispute stands even if nobody hears about it
+	_ = s.sendMatchDisputedEmail(c, user, match)
+
+	c.Response().Header().Set("HX-Redirect", fmt.Sprintf("/offices/%s/disputed/%d", officeCode, match.ID))
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) disputedMatchApproveHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, match, err := s.disputedMatchFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	err = s.app.ApproveDisputedMatch(user, match)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	c.Response().Header().Set("HX-Redirect", office.Link()+fmt.Sprintf("/disputed?game=%d", match.GameID))
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) disputedMatchCorrectHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, match, err := s.disputedMatchFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	bestOf, _ := strconv.Atoi(c.FormValue("bestOf"))
+	scores, err := app.ParseMatchScores(c.FormValue("scores"), bestOf, match.Game)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	swapSides := c.FormValue("swapSides") == "on"
+	err = s.app.CorrectDisputedMatch(user, match, swapSides, bestOf, scores)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	c.Response().Header().Set("HX-Redirect", office.Link()+fmt.Sprintf("/disputed?game=%d", match.GameID))
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) disputedMatchVoidHandler(c echo.Context) error {
+	office, match, err := s.disputedMatchFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	err = s.app.VoidDisputedMatch(match)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	c.Response().Header().Set("HX-Redirect", office.Link()+fmt.Sprintf("/disputed?game=%d", match.GameID))
+	return c.NoContent(http.StatusOK)
+}
+
+// sendMatchDisputedEmail lets the match's creator and the office admin know the
+// result has been disputed. Anyone without an email address is skipped.
+func (s *Server) sendMatchDisputedEmail(c echo.Context, disputedBy *db.User, match *db.Match) error {
+	admin := db.User{}
+	err := s.db.C.First(&admin, match.Office.AdminRefer).Error
+	if err != nil {
+		return err
+	}
+
+	to := []string{}
+	for _, user := range []db.User{match.Creator, admin} {
+		if user.Email == "" || user.ID == disputedBy.ID {
+			continue
+		}
+		if len(to) > 0 && to[0] == user.Email {
+			continue
+		}
+		to = append(to, user.Email)
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	host := c.Request().Host
+	emailBody := fmt.Sprintf(
+		"%s has disputed a match you're involved with: \"%s\". Click <a href=\"http://%s/offices/%s/disputed/%d\">here</a> to see it.",
+		html.EscapeString(disputedBy.Username), html.EscapeString(match.DisputeReason), host, match.Office.Code, match.ID,
+	)
+
+	return email.SendEmail(to, "Office Table Tennis - Match Disputed", emailBody)
+}
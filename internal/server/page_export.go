@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) ratingHistoryExportHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	snapshots, err := s.app.GetRatingSnapshots(game.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	filename := fmt.Sprintf("%s-%s-ratings.csv", office.Code, strings.ReplaceAll(strings.ToLower(game.Name), " ", "-"))
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+	w.Write([]string{"match", "date", "player", "points_before", "points_after", "points_applied"})
+	for _, snapshot := range snapshots {
+		w.Write([]string{
+			strconv.Itoa(int(snapshot.MatchID)),
+			snapshot.Match.PlayedAt.Format("2006-01-02 15:04"),
+			snapshot.User.Username,
+			strconv.Itoa(snapshot.PointsBefore),
+			strconv.Itoa(snapshot.PointsAfter),
+			strconv.Itoa(snapshot.PointsApplied()),
+		})
+	}
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+// gameFromRequest picks the office's game asked for with the game param, from
+// the query or the form. Without one, the office's first game is used.
+func (s *Server) gameFromRequest(c echo.Context, office *db.Office) (*db.Game, error) {
+	gameParam := c.FormValue("game")
+	for _, game := range office.Games {
+		if gameParam == "" || officeViews.GameParam(game) == gameParam {
+			return &game, nil
+		}
+	}
+
+	return nil, echo.NewHTTPError(http.StatusNotFound, "Game not found")
+}
+
+func (s *Server) createGameHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	formData := officeViews.GameFormData{Name: c.FormValue("name")}
+
+	userErr, err := s.app.CreateGame(office, formData.Name)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	if userErr != nil {
+		errs := officeViews.GameFormErrors{Game: userErr.Error()}
+		return render(c, http.StatusOK, officeViews.GamesSection(*office, formData, errs))
+	}
+
+	office, err = s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.GamesSection(*office, officeViews.GameFormData{}, officeViews.GameFormErrors{}))
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	headToHeadMeetingsShown = 10
+)
+
+// headToHeadFormHandler sends the player picker's query through to the page for
+// that pair.
+func (s *Server) headToHeadFormHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	player1Id, err := strconv.ParseUint(c.QueryParam("a"), 10, 0)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Pick two players")
+	}
+	player2Id, err := strconv.ParseUint(c.QueryParam("b"), 10, 0)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Pick two players")
+	}
+
+	url := fmt.Sprintf("/offices/%s/h2h/%d/%d", officeCode, player1Id, player2Id)
+	if game := c.QueryParam("game"); game != "" {
+		gameId, err := strconv.ParseUint(game, 10, 0)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Game not found")
+		}
+		url += fmt.Sprintf("?game=%d", gameId)
+	}
+	return c.Redirect(http.StatusSeeOther, url)
+}
+
+func (s *Server) headToHeadPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	player1 := officePlayer(office, c.Param("a"))
+	player2 := officePlayer(office, c.Param("b"))
+	if player1 == nil || player2 == nil {
+		return c.String(http.StatusNotFound, "Player not found")
+	}
+	if player1.ID == player2.ID {
+		return c.String(http.StatusBadRequest, "Pick two different players")
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	processedGame, err := s.gp.Process(game.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	h2h := processedGame.HeadToHead(*player1, *player2)
+
+	meetingIds := []uint{}
+	for _, meeting := range h2h.LastMeetings(headToHeadMeetingsShown) {
+		meetingIds = append(meetingIds, meeting.MatchID)
+	}
+
+	// Office matches are already newest first
+	lastMeetings := []db.Match{}
+	for _, match := range office.GameMatches(game.ID) {
+		if slices.Contains(meetingIds, match.ID) {
+			lastMeetings = append(lastMeetings, match)
+		}
+	}
+
+	return render(c, http.StatusOK, officeViews.HeadToHeadPage(officeViews.HeadToHeadPageProps{
+		Office:        *office,
+		Game:          *game,
+		User:          user,
+		HeadToHead:    h2h,
+		LastMeetings:  lastMeetings,
+		ProcessedGame: processedGame,
+	}))
+}
+
+func officePlayer(office *db.Office, id string) *db.User {
+	userId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil
+	}
+
+	for _, player := range office.Players {
+		if player.ID == uint(userId) {
+			return &player
+		}
+	}
+	return nil
+}
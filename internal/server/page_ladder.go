@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) ladderPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	positions, err := s.app.GetLadder(game)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	challenges, err := s.app.GetOutstandingLadderChallenges(game)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.LadderPage(officeViews.LadderPageProps{
+		Office:     *office,
+		Game:       *game,
+		User:       user,
+		Positions:  positions,
+		Challenges: challenges,
+	}))
+}
+
+func (s *Server) joinLadderHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	userErr, err := s.app.JoinLadder(user, game)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	if userErr != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(userErr.Error()))
+	}
+
+	c.Response().Header().Set("HX-Refresh", "true")
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) ladderChallengeHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	userErr, err := s.app.CreateLadderChallenge(user, game, c.FormValue("opponent"))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	if userErr != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(userErr.Error()))
+	}
+
+	c.Response().Header().Set("HX-Refresh", "true")
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/RowMur/office-table-tennis/internal/app"
+	"github.com/RowMur/office-table-tennis/internal/db"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+// approvedMatchFromRequest loads the office and the approved match in the path.
+func (s *Server) approvedMatchFromRequest(c echo.Context) (*db.Office, *db.Match, error) {
+	office, err := s.app.GetOfficeByCode(c.Param("code"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	match, err := s.app.GetMatchById(c.Param("matchId"))
+	if err != nil {
+		if db.IsRecordNotFoundError(err) {
+			return nil, nil, echo.NewHTTPError(http.StatusNotFound, "Match not found")
+		}
+
+		return nil, nil, err
+	}
+	if match.OfficeID != office.ID || match.State != db.MatchStateApproved {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, "Match not found")
+	}
+
+	return office, match, nil
+}
+
+func (s *Server) matchEditPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, match, err := s.approvedMatchFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	corrections, err := s.app.GetMatchCorrections(match.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.MatchEditPage(officeViews.MatchEditPageProps{
+		Office:      *office,
+		Match:       *match,
+		User:        user,
+		Corrections: corrections,
+	}))
+}
+
+func (s *Server) matchEditFormHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, match, err := s.approvedMatchFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	c.Request().ParseForm()
+	winners := c.Request().Form["Winners"]
+	losers := c.Request().Form["Losers"]
+	note := c.FormValue("note")
+	isHandicap := c.FormValue("isHandicap") == "on"
+
+	err = officeViews.ValidatePlayMatchForm(officeViews.PlayMatchFormData{
+		Note:    note,
+		Winners: winners,
+		Losers:  losers,
+	})
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(err))
+	}
+
+	bestOf, _ := strconv.Atoi(c.FormValue("bestOf"))
+	scores, err := app.ParseMatchScores(c.FormValue("scores"), bestOf, match.Game)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(err))
+	}
+
+	userErr, err := s.app.EditApprovedMatch(user, match, app.MatchDetails{
+		GameID:     match.GameID,
+		Note:       note,
+		Winners:    winners,
+		Losers:     losers,
+		IsHandicap: isHandicap,
+		BestOf:     bestOf,
+		Scores:     scores,
+	})
+	if userErr != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(userErr))
+	}
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(errors.New("Failed to edit the match")))
+	}
+
+	c.Response().Header().Set("HX-Redirect", officeViews.GameURL(*office, match.Game, "/matches"))
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) matchVoidHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, match, err := s.approvedMatchFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	userErr, err := s.app.VoidApprovedMatch(user, match)
+	if userErr != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(userErr))
+	}
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set("HX-Redirect", officeViews.GameURL(*office, match.Game, "/matches"))
+	return c.NoContent(http.StatusOK)
+}
@@ -5,10 +5,13 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/RowMur/office-table-tennis/internal/app"
 	"github.com/RowMur/office-table-tennis/internal/db"
+	"github.com/RowMur/office-table-tennis/internal/gameprocessor"
 	"github.com/RowMur/office-table-tennis/internal/views"
 	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 func (s *Server) officeHandler(c echo.Context) error {
@@ -26,22 +29,71 @@ func (s *Server) officeHandler(c echo.Context) error {
 		return c.Redirect(http.StatusTemporaryRedirect, "/sign-in")
 	}
 
-	processedGame, err := s.gp.Process(office.ID)
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	processedGame, err := s.gp.Process(game.ID)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	season, err := s.seasonFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	rankings := processedGame.RankedPlayers()
+	if season != nil {
+		rankings, err = s.app.SeasonRankings(game.ID, season)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
+
 	var pendingMatchCount int64
 	err = s.db.C.
 		Model(&db.Match{}).
-		Where("office_id = ? AND state = ?", office.ID, db.MatchStatePending).
+		Where("game_id = ? AND state = ?", game.ID, db.MatchStatePending).
 		Count(&pendingMatchCount).Error
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	var disputedMatchCount int64
+	err = s.db.C.
+		Model(&db.Match{}).
+		Where("game_id = ? AND state = ?", game.ID, db.MatchStateDisputed).
+		Count(&disputedMatchCount).Error
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	challenges, err := s.app.GetOutstandingGameChallenges(game)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	ladder := []db.LadderPosition{}
+	if game.Ladder.Enabled {
+		ladder, err = s.app.GetLadder(game)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, err.Error())
+		}
+	}
 
 	return render(c, http.StatusOK, officeViews.OfficePage(officeViews.OfficePageProps{
-		Office:            *office,
-		User:              user,
-		PendingMatchCount: int(pendingMatchCount),
-		ProcessedGame:     processedGame,
+		Office:             *office,
+		Game:               *game,
+		User:               user,
+		PendingMatchCount:  int(pendingMatchCount),
+		DisputedMatchCount: int(disputedMatchCount),
+		ChallengeCount:     len(challenges),
+		ProcessedGame:      processedGame,
+		Season:             season,
+		Rankings:           rankings,
+		Ladder:             ladder,
 	}))
 }
 
@@ -96,8 +148,14 @@ func (s *Server) gamesPlayPageHandler(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
 	endpoint := office.Link() + "/play"
-	return render(c, http.StatusOK, officeViews.PlayGamePage(*office, office.Players, endpoint, user))
+	isSession := c.QueryParam("mode") == "session"
+	return render(c, http.StatusOK, officeViews.PlayGamePage(*office, *game, office.Players, endpoint, user, isSession))
 }
 
 func (s *Server) gamesPlayFormHandler(c echo.Context) error {
@@ -124,7 +182,32 @@ func (s *Server) gamesPlayFormHandler(c echo.Context) error {
 		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(err))
 	}
 
-	match, err := s.app.LogMatch(user, office, note, winners, losers, isHandicap)
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	bestOf, _ := strconv.Atoi(c.FormValue("bestOf"))
+	scores, err := app.ParseMatchScores(c.FormValue("scores"), bestOf, *game)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(err))
+	}
+
+	playedAt, err := app.ParsePlayedAt(c.FormValue("playedAt"), c.FormValue("tzOffset"))
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(err))
+	}
+
+	match, err := s.app.LogMatch(user, office, app.MatchDetails{
+		GameID:     game.ID,
+		PlayedAt:   playedAt,
+		Note:       note,
+		Winners:    winners,
+		Losers:     losers,
+		IsHandicap: isHandicap,
+		BestOf:     bestOf,
+		Scores:     scores,
+	})
 	if err != nil {
 		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(err))
 	}
@@ -132,7 +215,7 @@ func (s *Server) gamesPlayFormHandler(c echo.Context) error {
 	// Not the end of the world if the auto approve doesnt work
 	_ = s.app.ApproveMatch(user, match)
 	if match.IsApproved() {
-		c.Response().Header().Set("HX-Redirect", office.Link())
+		c.Response().Header().Set("HX-Redirect", officeViews.GameURL(*office, *game, ""))
 		return c.NoContent(http.StatusOK)
 	}
 
@@ -150,19 +233,27 @@ func (s *Server) gamePendingMatchesPage(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
 	var pendingMatches []db.Match
 	err = s.db.C.
-		Where("office_id = ? AND state = ?", office.ID, db.MatchStatePending).
+		Where("game_id = ? AND state = ?", game.ID, db.MatchStatePending).
 		Order("created_at DESC").
 		Preload("Participants.User").
 		Preload("Creator").
 		Preload("Approvals").
+		Preload("Scores", func(db *gorm.DB) *gorm.DB {
+			return db.Order("number")
+		}).
 		Find(&pendingMatches).Error
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	return render(c, http.StatusOK, officeViews.PendingMatchesPage(*office, pendingMatches, user))
+	return render(c, http.StatusOK, officeViews.PendingMatchesPage(*office, *game, pendingMatches, user))
 }
 
 func (s *Server) pendingMatchPage(c echo.Context) error {
@@ -175,12 +266,16 @@ func (s *Server) pendingMatchPage(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	// Check if this match is still pending
-	if match.State != db.MatchStatePending {
+	if match.State == db.MatchStateDisputed {
+		return c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("/offices/%s/disputed/%d", officeCode, match.ID))
+	}
+
+	// Expired matches are kept so their players can see what happened to them
+	if match.State != db.MatchStatePending && match.State != db.MatchStateExpired {
 		return c.Redirect(http.StatusTemporaryRedirect, fmt.Sprintf("/offices/%s", officeCode))
 	}
 
-	return render(c, http.StatusOK, officeViews.PendingMatchPage(match.Office, *match, user))
+	return render(c, http.StatusOK, officeViews.PendingMatchPage(match.Office, *match, user, app.PendingMatchSettlesAt(match.Office, *match)))
 }
 
 func (s *Server) pendingMatchApproveHandler(c echo.Context) error {
@@ -203,7 +298,7 @@ func (s *Server) pendingMatchApproveHandler(c echo.Context) error {
 		return c.NoContent(http.StatusOK)
 	}
 
-	c.Response().Header().Set("HX-Redirect", fmt.Sprintf("/offices/%s/pending", officeCode))
+	c.Response().Header().Set("HX-Redirect", fmt.Sprintf("/offices/%s/pending?game=%d", officeCode, match.GameID))
 	return c.NoContent(http.StatusOK)
 }
 
@@ -232,7 +327,7 @@ func (s *Server) pendingMatchDeleteHandler(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	c.Response().Header().Set("HX-Redirect", fmt.Sprintf("/offices/%s/pending", officeCode))
+	c.Response().Header().Set("HX-Redirect", fmt.Sprintf("/offices/%s/pending?game=%d", officeCode, match.GameID))
 	return c.NoContent(http.StatusOK)
 }
 
@@ -254,26 +349,46 @@ func (s *Server) matchesPageHandler(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	season, err := s.seasonFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	matches := office.GameMatches(game.ID)
+	if season != nil {
+		matches = []db.Match{}
+		for _, match := range office.GameMatches(game.ID) {
+			if season.Contains(match.PlayedAt) {
+				matches = append(matches, match)
+			}
+		}
+	}
+
 	pageInt, err := strconv.Atoi(page)
 	if err != nil || pageInt < 0 {
 		return c.String(http.StatusBadRequest, "Invalid page number")
 	}
 
 	startingIndex := pageInt * matchesPerPage
-	if startingIndex > len(office.Matches)-1 {
+	if startingIndex > len(matches)-1 && pageInt > 0 {
 		return c.String(http.StatusNotFound, "Page not found")
 	}
 
-	endingIndex := min(startingIndex+matchesPerPage, len(office.Matches))
-	matchesToReturn := office.Matches[startingIndex:endingIndex]
+	endingIndex := min(startingIndex+matchesPerPage, len(matches))
+	matchesToReturn := matches[startingIndex:endingIndex]
 
-	hasNextPage := len(office.Matches) > endingIndex
+	hasNextPage := len(matches) > endingIndex
 	nextPage := ""
 	if hasNextPage {
 		nextPage = strconv.Itoa(pageInt + 1)
 	}
 
-	processedGame, err := s.gp.Process(office.ID)
+	points, err := s.matchPoints(game, season, matchesToReturn)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
@@ -282,17 +397,40 @@ func (s *Server) matchesPageHandler(c echo.Context) error {
 		// full page
 		return render(c, http.StatusOK, officeViews.MatchesPage(
 			officeViews.MatchesPageProps{
-				User:          user,
-				Matches:       matchesToReturn,
-				Office:        *office,
-				NextPage:      nextPage,
-				ProcessedGame: processedGame,
+				User:     user,
+				Matches:  matchesToReturn,
+				Office:   *office,
+				Game:     *game,
+				NextPage: nextPage,
+				Points:   points,
+				Season:   season,
 			},
 		))
 	}
 
 	// partial page
-	return render(c, http.StatusOK, officeViews.Matches(officeViews.MatchesProps{Matches: matchesToReturn, NextPage: nextPage, ProcessedGame: processedGame, Office: *office}))
+	return render(c, http.StatusOK, officeViews.Matches(officeViews.MatchesProps{Matches: matchesToReturn, NextPage: nextPage, Points: points, Office: *office, Game: *game, Season: season, CanEdit: user.ID == office.AdminRefer}))
+}
+
+// matchPoints is the points won and lost in the matches. All time they come from
+// the rating snapshots, but a season's ratings start afresh so it's processed.
+func (s *Server) matchPoints(game *db.Game, season *db.Season, matches []db.Match) (gameprocessor.MatchPoints, error) {
+	if season == nil {
+		return s.app.GetMatchPoints(matches)
+	}
+
+	return s.gp.ProcessSeason(game.ID, *season)
+}
+
+// ratingHistory is the player's points after each of their matches. All time it
+// comes from the rating snapshots, but a season's ratings start afresh so it's
+// taken from the processed season.
+func (s *Server) ratingHistory(game *db.Game, season *db.Season, processedGame *gameprocessor.Game, userId uint) ([]gameprocessor.RatingPoint, error) {
+	if season == nil {
+		return s.app.GetRatingHistory(game.ID, userId)
+	}
+
+	return processedGame.RatingHistory(userId), nil
 }
 
 func (s *Server) gameStatsPageHandler(c echo.Context) error {
@@ -304,12 +442,32 @@ func (s *Server) gameStatsPageHandler(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	processedGame, err := s.gp.Process(office.ID)
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	season, err := s.seasonFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	processedGame, err := s.processedGameForSeason(game, season)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	allTimeGame, err := s.gp.Process(game.ID)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	return render(c, http.StatusOK, officeViews.StatsPage(*office, user, *processedGame))
+	history, err := s.ratingHistory(game, season, processedGame, user.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.StatsPage(*office, *game, user, *processedGame, *allTimeGame, season, history))
 }
 
 func (s *Server) gamePlayerStatsPostHandler(c echo.Context) error {
@@ -326,7 +484,17 @@ func (s *Server) gamePlayerStatsPostHandler(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
-	processedGame, err := s.gp.Process(office.ID)
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	season, err := s.seasonFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	processedGame, err := s.processedGameForSeason(game, season)
 	if err != nil {
 		return c.String(http.StatusInternalServerError, err.Error())
 	}
@@ -336,5 +504,18 @@ func (s *Server) gamePlayerStatsPostHandler(c echo.Context) error {
 		return render(c, http.StatusOK, officeViews.PlayerHasntPlayedYet())
 	}
 
-	return render(c, http.StatusOK, officeViews.PlayerStats(*processedGame, *player))
+	history, err := s.ratingHistory(game, season, processedGame, player.User.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.PlayerStats(*processedGame, *player, history))
+}
+
+func (s *Server) processedGameForSeason(game *db.Game, season *db.Season) (*gameprocessor.Game, error) {
+	if season == nil {
+		return s.gp.Process(game.ID)
+	}
+
+	return s.gp.ProcessSeason(game.ID, *season)
 }
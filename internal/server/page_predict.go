@@ -0,0 +1,114 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"github.com/RowMur/office-table-tennis/internal/gameprocessor"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) predictPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	c.Request().ParseForm()
+	props := officeViews.PredictPageProps{
+		Office:     *office,
+		Game:       *game,
+		User:       user,
+		Side1:      c.Request().Form["side1"],
+		Side2:      c.Request().Form["side2"],
+		IsHandicap: c.FormValue("isHandicap") == "on",
+	}
+
+	if len(props.Side1) > 0 || len(props.Side2) > 0 {
+		props.Prediction, props.Err = s.predict(office, game, props.Side1, props.Side2, props.IsHandicap)
+	}
+
+	return render(c, http.StatusOK, officeViews.PredictPage(props))
+}
+
+// playPreviewHandler shows what is at stake for the match being filled in on
+// the play page.
+func (s *Server) playPreviewHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	c.Request().ParseForm()
+	winners := c.Request().Form["Winners"]
+	losers := c.Request().Form["Losers"]
+	isHandicap := c.FormValue("isHandicap") == "on"
+
+	prediction, err := s.predict(office, game, winners, losers, isHandicap)
+	if err != nil {
+		// Nothing to preview until both sides are picked
+		return c.NoContent(http.StatusOK)
+	}
+
+	return render(c, http.StatusOK, officeViews.MatchPrediction(*prediction, "Winners", "Losers"))
+}
+
+func (s *Server) predict(office *db.Office, game *db.Game, side1Ids, side2Ids []string, isHandicap bool) (*gameprocessor.Prediction, error) {
+	side1, err := predictionSide(office, side1Ids)
+	if err != nil {
+		return nil, err
+	}
+	side2, err := predictionSide(office, side2Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, player := range side1 {
+		for _, opponent := range side2 {
+			if player.ID == opponent.ID {
+				return nil, errors.New("A player can only be in one team")
+			}
+		}
+	}
+
+	processedGame, err := s.gp.Process(game.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	prediction := processedGame.Predict(side1, side2, isHandicap)
+	return &prediction, nil
+}
+
+func predictionSide(office *db.Office, ids []string) ([]db.User, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("Both sides need at least one player")
+	}
+
+	side := []db.User{}
+	for _, id := range ids {
+		player := officePlayer(office, id)
+		if player == nil {
+			return nil, errors.New("Player not found")
+		}
+		side = append(side, *player)
+	}
+	return side, nil
+}
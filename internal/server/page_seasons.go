@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+// seasonFromRequest picks the season asked for with the season query param.
+// Without one, the current season is used if there is one, "all" is all time
+// which is returned as nil.
+func (s *Server) seasonFromRequest(c echo.Context, office *db.Office) (*db.Season, error) {
+	seasonParam := c.QueryParam("season")
+	if seasonParam == "all" {
+		return nil, nil
+	}
+
+	if seasonParam == "" {
+		for _, season := range office.Seasons {
+			if season.IsActive() {
+				return &season, nil
+			}
+		}
+		return nil, nil
+	}
+
+	season, err := s.app.GetSeasonById(office.ID, seasonParam)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Season not found")
+	}
+
+	return season, nil
+}
+
+func (s *Server) createSeasonHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	formData := officeViews.SeasonFormData{
+		Name:      c.FormValue("name"),
+		StartDate: c.FormValue("startDate"),
+		EndDate:   c.FormValue("endDate"),
+	}
+
+	startDate, startErr := time.Parse(time.DateOnly, formData.StartDate)
+	endDate, endErr := time.Parse(time.DateOnly, formData.EndDate)
+	if startErr != nil || endErr != nil {
+		errs := officeViews.SeasonFormErrors{Season: "Start and end dates are required"}
+		return render(c, http.StatusOK, officeViews.SeasonsSection(*office, office.Seasons, formData, errs))
+	}
+
+	userErr, err := s.app.CreateSeason(office, formData.Name, startDate, endDate)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	if userErr != nil {
+		errs := officeViews.SeasonFormErrors{Season: userErr.Error()}
+		return render(c, http.StatusOK, officeViews.SeasonsSection(*office, office.Seasons, formData, errs))
+	}
+
+	office, err = s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.SeasonsSection(*office, office.Seasons, officeViews.SeasonFormData{}, officeViews.SeasonFormErrors{}))
+}
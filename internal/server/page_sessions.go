@@ -0,0 +1,140 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RowMur/office-table-tennis/internal/app"
+	"github.com/RowMur/office-table-tennis/internal/db"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+// sessionFromRequest loads the office and the match session in the path.
+func (s *Server) sessionFromRequest(c echo.Context) (*db.Office, *db.MatchSession, error) {
+	office, err := s.app.GetOfficeByCode(c.Param("code"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := s.app.GetMatchSessionById(office.ID, c.Param("sessionId"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if session == nil {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, "Session not found")
+	}
+
+	return office, session, nil
+}
+
+func (s *Server) sessionPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, session, err := s.sessionFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	return render(c, http.StatusOK, officeViews.SessionPage(*office, *session, user))
+}
+
+func (s *Server) sessionMatchRowHandler(c echo.Context) error {
+	office, err := s.app.GetOfficeByCode(c.Param("code"))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	index, err := strconv.Atoi(c.QueryParam("index"))
+	if err != nil || index < 0 || index >= officeViews.SessionMaxMatches {
+		return c.String(http.StatusBadRequest, "Invalid match number")
+	}
+
+	return render(c, http.StatusOK, officeViews.SessionMatchRowResponse(*office, office.Players, index))
+}
+
+func (s *Server) sessionFormHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, err := s.app.GetOfficeByCode(c.Param("code"))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	playedAt, err := app.ParsePlayedAt(c.FormValue("playedAt"), c.FormValue("tzOffset"))
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(err))
+	}
+
+	c.Request().ParseForm()
+	bestOf, _ := strconv.Atoi(c.FormValue("bestOf"))
+	matches := []app.MatchDetails{}
+	for i := range officeViews.SessionMaxMatches {
+		winners := c.Request().Form[officeViews.SessionMatchField("Winners", i)]
+		losers := c.Request().Form[officeViews.SessionMatchField("Losers", i)]
+		scoresInput := c.FormValue(officeViews.SessionMatchField("scores", i))
+		if len(winners) == 0 && len(losers) == 0 && scoresInput == "" {
+			continue
+		}
+
+		err = officeViews.ValidatePlayMatchForm(officeViews.PlayMatchFormData{
+			Winners: winners,
+			Losers:  losers,
+		})
+		if err != nil {
+			return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(fmt.Errorf("Match %d: %w", i+1, err)))
+		}
+
+		scores, err := app.ParseMatchScores(scoresInput, bestOf, *game)
+		if err != nil {
+			return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(fmt.Errorf("Match %d: %w", i+1, err)))
+		}
+
+		matches = append(matches, app.MatchDetails{
+			Winners: winners,
+			Losers:  losers,
+			BestOf:  bestOf,
+			Scores:  scores,
+		})
+	}
+
+	session, err := s.app.LogMatchSession(user, office, game, playedAt, matches)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.PlayMatchFormErrors(err))
+	}
+
+	// Not the end of the world if the auto approve doesnt work
+	loggedSession, err := s.app.GetMatchSessionById(office.ID, strconv.Itoa(int(session.ID)))
+	if err == nil && loggedSession != nil {
+		_, _ = s.app.ApproveMatchSession(user, loggedSession)
+	}
+
+	c.Response().Header().Set("HX-Redirect", fmt.Sprintf("%s/sessions/%d", office.Link(), session.ID))
+	return c.NoContent(http.StatusOK)
+}
+
+func (s *Server) sessionApproveHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	_, session, err := s.sessionFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	userErr, err := s.app.ApproveMatchSession(user, session)
+	if userErr != nil {
+		return render(c, http.StatusOK, officeViews.MatchApproveError(userErr.Error()))
+	}
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchApproveError(err.Error()))
+	}
+
+	c.Response().Header().Set("HX-Refresh", "true")
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) settingsPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	return render(c, http.StatusOK, officeViews.SettingsPage(*office, *game, user, officeViews.NewSettingsFormData(*game)))
+}
+
+func (s *Server) settingsFormHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	formData := officeViews.SettingsFormData{
+		RatingSystem:          c.FormValue("ratingSystem"),
+		StartingPoints:        c.FormValue("startingPoints"),
+		KFactor:               c.FormValue("kFactor"),
+		PointsFloor:           c.FormValue("pointsFloor"),
+		ProvisionalMatches:    c.FormValue("provisionalMatches"),
+		ProvisionalMultiplier: c.FormValue("provisionalMultiplier"),
+		HandicapMultiplier:    c.FormValue("handicapMultiplier"),
+		InactivityWeeks:       c.FormValue("inactivityWeeks"),
+		GameTarget:            c.FormValue("gameTarget"),
+		WinByTwo:              c.FormValue("winByTwo") == "on",
+		MarginOfVictory:       c.FormValue("marginOfVictory"),
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	settings, gameTarget, errs := officeViews.ParseSettingsForm(formData)
+	if errs.Any() {
+		return render(c, http.StatusOK, officeViews.SettingsForm(*office, *game, formData, errs, nil))
+	}
+
+	err = s.app.UpdateGameSettings(game, formData.RatingSystem, gameTarget, formData.WinByTwo, settings)
+	if err != nil {
+		falseVar := false
+		return render(c, http.StatusOK, officeViews.SettingsForm(*office, *game, formData, errs, &falseVar))
+	}
+
+	truePtr := true
+	return render(c, http.StatusOK, officeViews.SettingsForm(*office, *game, formData, errs, &truePtr))
+}
+
+func (s *Server) ladderSettingsFormHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	formData := officeViews.LadderSettingsFormData{
+		Enabled:       c.FormValue("enabled") == "on",
+		Reach:         c.FormValue("reach"),
+		ChallengeDays: c.FormValue("challengeDays"),
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	settings, errs := officeViews.ParseLadderSettingsForm(formData)
+	if errs.Any() {
+		return render(c, http.StatusOK, officeViews.LadderSettingsForm(*office, *game, formData, errs, nil))
+	}
+
+	err = s.app.UpdateLadderSettings(game, settings)
+	if err != nil {
+		falseVar := false
+		return render(c, http.StatusOK, officeViews.LadderSettingsForm(*office, *game, formData, errs, &falseVar))
+	}
+
+	truePtr := true
+	return render(c, http.StatusOK, officeViews.LadderSettingsForm(*office, *game, formData, errs, &truePtr))
+}
+
+func (s *Server) pendingMatchSettingsFormHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	formData := officeViews.PendingMatchSettingsFormData{
+		Days:        c.FormValue("days"),
+		AutoApprove: c.FormValue("autoApprove") == "on",
+	}
+
+	settings, errs := officeViews.ParsePendingMatchSettingsForm(formData)
+	if errs.Any() {
+		return render(c, http.StatusOK, officeViews.PendingMatchSettingsForm(*office, formData, errs, nil))
+	}
+
+	err = s.app.UpdatePendingMatchSettings(office, settings)
+	if err != nil {
+		falseVar := false
+		return render(c, http.StatusOK, officeViews.PendingMatchSettingsForm(*office, formData, errs, &falseVar))
+	}
+
+	truePtr := true
+	return render(c, http.StatusOK, officeViews.PendingMatchSettingsForm(*office, formData, errs, &truePtr))
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/db"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Partners from within this long ago count as recent
+	recentPartnerWindow = 7 * 24 * time.Hour
+)
+
+func (s *Server) teamsPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	c.Request().ParseForm()
+	props := officeViews.TeamsPageProps{
+		Office:      *office,
+		Game:        *game,
+		User:        user,
+		Selected:    c.Request().Form["players"],
+		AvoidRecent: c.FormValue("avoidRecent") == "on",
+	}
+
+	if len(props.Selected) == 0 {
+		return render(c, http.StatusOK, officeViews.TeamsPage(props))
+	}
+
+	if len(props.Selected) < officeViews.MinTeamPlayers || len(props.Selected) > officeViews.MaxTeamPlayers {
+		props.Err = fmt.Errorf("Pick between %d and %d players", officeViews.MinTeamPlayers, officeViews.MaxTeamPlayers)
+		return render(c, http.StatusOK, officeViews.TeamsPage(props))
+	}
+
+	players := []db.User{}
+	for _, id := range props.Selected {
+		player := officePlayer(office, id)
+		if player == nil {
+			return c.String(http.StatusBadRequest, "Player not found")
+		}
+		players = append(players, *player)
+	}
+
+	processedGame, err := s.gp.Process(game.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	recentSince := time.Time{}
+	if props.AvoidRecent {
+		recentSince = time.Now().Add(-recentPartnerWindow)
+	}
+
+	props.Splits = processedGame.BalancedTeams(players, recentSince)
+	return render(c, http.StatusOK, officeViews.TeamsPage(props))
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/RowMur/office-table-tennis/internal/app"
+	"github.com/RowMur/office-table-tennis/internal/db"
+	officeViews "github.com/RowMur/office-table-tennis/internal/views/office"
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) tournamentsPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	tournaments, err := s.app.GetTournaments(office.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	return render(c, http.StatusOK, officeViews.TournamentsPage(*office, tournaments, user))
+}
+
+func (s *Server) createTournamentHandler(c echo.Context) error {
+	officeCode := c.Param("code")
+
+	office, err := s.app.GetOfficeByCode(officeCode)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Request().ParseForm()
+	formData := officeViews.TournamentFormData{
+		Name:         c.FormValue("name"),
+		Game:         c.FormValue("game"),
+		Format:       c.FormValue("format"),
+		Players:      c.Request().Form["players"],
+		Legs:         c.FormValue("legs"),
+		Rounds:       c.FormValue("rounds"),
+		PointsForWin: c.FormValue("pointsForWin"),
+	}
+
+	legs, _ := strconv.Atoi(formData.Legs)
+	rounds, _ := strconv.Atoi(formData.Rounds)
+	pointsForWin, _ := strconv.Atoi(formData.PointsForWin)
+	game, err := s.gameFromRequest(c, office)
+	if err != nil {
+		return err
+	}
+
+	tournament, userErr, err := s.app.CreateTournament(office, game, app.TournamentDetails{
+		Name:         formData.Name,
+		Format:       formData.Format,
+		Players:      formData.Players,
+		Legs:         legs,
+		Rounds:       rounds,
+		PointsForWin: pointsForWin,
+	})
+	if err != nil {
+		return c.String(http.StatusInternalServerError, err.Error())
+	}
+	if userErr != nil {
+		errs := officeViews.TournamentFormErrors{Tournament: userErr.Error()}
+		return render(c, http.StatusOK, officeViews.TournamentForm(*office, formData, errs))
+	}
+
+	c.Response().Header().Set("HX-Redirect", officeViews.TournamentLink(*office, *tournament))
+	return c.NoContent(http.StatusOK)
+}
+
+// tournamentFromRequest loads the office and tournament in the path.
+func (s *Server) tournamentFromRequest(c echo.Context) (*db.Office, *db.Tournament, error) {
+	office, err := s.app.GetOfficeByCode(c.Param("code"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tournament, err := s.app.GetTournamentById(office.ID, c.Param("tournamentId"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if tournament == nil {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, "Tournament not found")
+	}
+
+	return office, tournament, nil
+}
+
+func (s *Server) tournamentPageHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, tournament, err := s.tournamentFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	return render(c, http.StatusOK, officeViews.TournamentPage(*office, *tournament, user))
+}
+
+func (s *Server) fixturePageHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, tournament, err := s.tournamentFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	fixture := s.app.GetTournamentFixture(tournament, c.Param("fixtureId"))
+	if fixture == nil || !fixture.IsReady() {
+		return c.Redirect(http.StatusTemporaryRedirect, officeViews.TournamentLink(*office, *tournament))
+	}
+
+	return render(c, http.StatusOK, officeViews.FixturePage(*office, *tournament, *fixture, user))
+}
+
+func (s *Server) fixtureResultHandler(c echo.Context) error {
+	user := userFromContext(c)
+
+	office, tournament, err := s.tournamentFromRequest(c)
+	if err != nil {
+		return err
+	}
+
+	fixture := s.app.GetTournamentFixture(tournament, c.Param("fixtureId"))
+	if fixture == nil {
+		return c.String(http.StatusNotFound, "Match not found")
+	}
+
+	bestOf, _ := strconv.Atoi(c.FormValue("bestOf"))
+	scores, err := app.ParseMatchScores(c.FormValue("scores"), bestOf, tournament.Game)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	match, err := s.app.LogTournamentMatch(user, office, tournament, fixture, c.FormValue("winner"), bestOf, scores)
+	if err != nil {
+		return render(c, http.StatusOK, officeViews.MatchResultError(err.Error()))
+	}
+
+	// Not the end of the world if the auto approve doesnt work
+	_ = s.app.ApproveMatch(user, match)
+	if match.IsApproved() {
+		c.Response().Header().Set("HX-Redirect", officeViews.TournamentLink(*office, *tournament))
+		return c.NoContent(http.StatusOK)
+	}
+
+	c.Response().Header().Set("HX-Redirect", office.Link()+fmt.Sprintf("/pending/%d", match.ID))
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/RowMur/office-table-tennis/internal/app"
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"github.com/RowMur/office-table-tennis/internal/email"
+)
+
+const pendingMatchWorkerInterval = time.Hour
+
+// runPendingMatchWorker warns the players of matches that have been pending for
+// too long, then settles the ones they've already been warned about. Ladder
+// challenges past their deadline are settled too.
+func (s *Server) runPendingMatchWorker() {
+	ticker := time.NewTicker(pendingMatchWorkerInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkPendingMatches()
+		s.checkLadderChallenges()
+		<-ticker.C
+	}
+}
+
+func (s *Server) checkLadderChallenges() {
+	err := s.app.SettleExpiredLadderChallenges()
+	if err != nil {
+		fmt.Printf("Error settling expired ladder challenges: %v\n", err)
+	}
+}
+
+func (s *Server) checkPendingMatches() {
+	warned, err := s.app.WarnStalePendingMatches()
+	if err != nil {
+		fmt.Printf("Error warning stale pending matches: %v\n", err)
+	}
+	for _, match := range warned {
+		err = sendPendingMatchWarningEmail(match)
+		if err != nil {
+			fmt.Printf("Error sending pending match warning for match %d: %v\n", match.ID, err)
+		}
+	}
+
+	err = s.app.SettleStalePendingMatches()
+	if err != nil {
+		fmt.Printf("Error settling stale pending matches: %v\n", err)
+	}
+}
+
+// sendPendingMatchWarningEmail tells the players who haven't approved the match
+// yet what is about to happen to it.
+func sendPendingMatchWarningEmail(match db.Match) error {
+	to := []string{}
+	for _, participant := range match.Participants {
+		if participant.User.Email == "" || match.IsApprovedByUser(participant.UserID) {
+			continue
+		}
+		to = append(to, participant.User.Email)
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	outcome := "Unless it's approved, it will expire"
+	if match.IsApprovedOnExpiry() {
+		outcome = "Unless it's disputed, it will be approved automatically"
+	}
+	settlesAt := app.PendingMatchSettlesAt(match.Office, match)
+
+	emailBody := fmt.Sprintf(
+		"The %s match %s logged at %s is still waiting for approval. %s on %s. Click <a href=\"%s%s/pending/%d\">here</a> to see it.",
+		html.EscapeString(match.Game.Name), html.EscapeString(match.Creator.Username), html.EscapeString(match.Office.Name),
+		outcome, settlesAt.Format("02/01/06"), siteURL, match.Office.Link(), match.ID,
+	)
+
+	return email.SendEmail(to, "Office Table Tennis - Pending Match", emailBody)
+}
@@ -12,6 +12,9 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 )
 
+// Where the site is hosted, for links sent outside of a request
+const siteURL = "https://office-table-tennis.rowmur.dev"
+
 type Server struct {
 	us  *user.UserService
 	db  *db.Database
@@ -37,7 +40,7 @@ func (s *Server) Run() {
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			if strings.Contains(c.Request().Host, "office-games") {
-				return c.Redirect(301, fmt.Sprintf("https://office-table-tennis.rowmur.dev%s", c.Request().URL.Path))
+				return c.Redirect(301, fmt.Sprintf("%s%s", siteURL, c.Request().URL.Path))
 			}
 			return next(c)
 		}
@@ -48,6 +51,7 @@ func (s *Server) Run() {
 	signedIn := e.Group("", enforceSignedIn)
 	signedOut := e.Group("", enforceSignedOut)
 	officeMember := signedIn.Group("", s.enforceMember)
+	officeAdmin := officeMember.Group("", s.enforceAdmin)
 
 	e.GET("/", pageHandler)
 	e.GET("/faqs", faqPageHandler)
@@ -78,16 +82,60 @@ func (s *Server) Run() {
 
 	officeMember.GET("/offices/:code/play", s.gamesPlayPageHandler)
 	officeMember.POST("/offices/:code/play", s.gamesPlayFormHandler)
+	officeMember.POST("/offices/:code/play/preview", s.playPreviewHandler)
+	officeMember.POST("/offices/:code/play/session", s.sessionFormHandler)
+	officeMember.GET("/offices/:code/play/session/row", s.sessionMatchRowHandler)
+	officeMember.GET("/offices/:code/sessions/:sessionId", s.sessionPageHandler)
+	officeMember.POST("/offices/:code/sessions/:sessionId/approve", s.sessionApproveHandler)
+	officeMember.GET("/offices/:code/predict", s.predictPageHandler)
+	officeMember.GET("/offices/:code/teams", s.teamsPageHandler)
 
 	officeMember.GET("/offices/:code/pending", s.gamePendingMatchesPage)
 	officeMember.GET("/offices/:code/pending/:matchId", s.pendingMatchPage)
 	officeMember.GET("/offices/:code/pending/:matchId/approve", s.pendingMatchApproveHandler)
 	officeMember.DELETE("/offices/:code/pending/:matchId/delete", s.pendingMatchDeleteHandler)
+	officeMember.POST("/offices/:code/pending/:matchId/dispute", s.pendingMatchDisputeHandler)
+
+	officeMember.GET("/offices/:code/disputed", s.disputedMatchesPageHandler)
+	officeMember.GET("/offices/:code/disputed/:matchId", s.disputedMatchPageHandler)
 
 	officeMember.GET("/offices/:code/matches", s.matchesPageHandler)
 
+	officeMember.GET("/offices/:code/challenges", s.challengesPageHandler)
+	officeMember.POST("/offices/:code/challenges", s.createChallengeHandler)
+	officeMember.GET("/offices/:code/challenges/:challengeId", s.challengePageHandler)
+	officeMember.POST("/offices/:code/challenges/:challengeId/accept", s.challengeAcceptHandler)
+	officeMember.POST("/offices/:code/challenges/:challengeId/decline", s.challengeDeclineHandler)
+	officeMember.POST("/offices/:code/challenges/:challengeId/result", s.challengeResultHandler)
+
+	officeMember.GET("/offices/:code/ladder", s.ladderPageHandler)
+	officeMember.POST("/offices/:code/ladder/join", s.joinLadderHandler)
+	officeMember.POST("/offices/:code/ladder/challenges", s.ladderChallengeHandler)
+
+	officeMember.GET("/offices/:code/tournaments", s.tournamentsPageHandler)
+	officeMember.GET("/offices/:code/tournaments/:tournamentId", s.tournamentPageHandler)
+	officeMember.GET("/offices/:code/tournaments/:tournamentId/fixtures/:fixtureId", s.fixturePageHandler)
+	officeMember.POST("/offices/:code/tournaments/:tournamentId/fixtures/:fixtureId/result", s.fixtureResultHandler)
+
 	officeMember.GET("/offices/:code/stats", s.gameStatsPageHandler)
 	officeMember.POST("/offices/:code/stats", s.gamePlayerStatsPostHandler)
+	officeMember.GET("/offices/:code/export/ratings.csv", s.ratingHistoryExportHandler)
+	officeMember.GET("/offices/:code/h2h", s.headToHeadFormHandler)
+	officeMember.GET("/offices/:code/h2h/:a/:b", s.headToHeadPageHandler)
+
+	officeAdmin.GET("/offices/:code/settings", s.settingsPageHandler)
+	officeAdmin.POST("/offices/:code/settings", s.settingsFormHandler)
+	officeAdmin.POST("/offices/:code/settings/ladder", s.ladderSettingsFormHandler)
+	officeAdmin.POST("/offices/:code/settings/pending", s.pendingMatchSettingsFormHandler)
+	officeAdmin.POST("/offices/:code/games", s.createGameHandler)
+	officeAdmin.POST("/offices/:code/seasons", s.createSeasonHandler)
+	officeAdmin.POST("/offices/:code/tournaments", s.createTournamentHandler)
+	officeAdmin.POST("/offices/:code/disputed/:matchId/approve", s.disputedMatchApproveHandler)
+	officeAdmin.POST("/offices/:code/disputed/:matchId/correct", s.disputedMatchCorrectHandler)
+	officeAdmin.POST("/offices/:code/disputed/:matchId/void", s.disputedMatchVoidHandler)
+	officeAdmin.GET("/offices/:code/matches/:matchId/edit", s.matchEditPageHandler)
+	officeAdmin.POST("/offices/:code/matches/:matchId/edit", s.matchEditFormHandler)
+	officeAdmin.POST("/offices/:code/matches/:matchId/void", s.matchVoidHandler)
 
 	signedIn.GET("/elo", s.eloPageHandler)
 
@@ -95,5 +143,7 @@ func (s *Server) Run() {
 		return c.Redirect(301, "/offices/"+c.Param("code"))
 	})
 
+	go s.runPendingMatchWorker()
+
 	e.Logger.Fatal(e.Start(":8080"))
 }
@@ -46,7 +46,7 @@ func FaqPage(user *db.User) templ.Component {
 				}()
 			}
 			ctx = templ.InitializeContext(ctx)
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<main class=\"mx-6 my-8\"><h2 class=\"text-2xl font-bold\">FAQs</h2><section class=\"my-4\"><h3 class=\"text-lg font-semibold\">What is Office Table Tennis?</h3><p>An online tracker for table tennis. You can create an office which is a space you can invite others to. Each office has it's own ranking system.</p></section><section class=\"my-4\"><h3 class=\"text-lg font-semibold\">How our ELOs calculated?</h3><p>Every player starts on 400 ELO points. When a match is played, an expected score is calculated with the two players ELOs (in the case of a team game, the team's ELO is the average of each of the players)...</p><div class=\"flex justify-center items-center gap-2 my-4\"><span>Expected=</span><div><p class=\"text-center mx-auto\">1</p><hr><p class=\"text-center mx-auto\">1 + 10<sup>(loserElo-winnerElo)/400</sup></p></div></div><p>...then the ELO gained/lost can be calculated with the following...</p><div class=\"flex justify-center my-4\">&pm; ELO = 32 * (1 - Expected)</div><p>Before applying ELO to the participants, there are a couple of checks:</p><ul class=\"list-disc my-4\"><li>Minimum ELO is 200. If applying the ELO puts a player below that, they just stay at 200.</li><li>A players first 20 matches are double points (gain and loss) to move them more quickly to their proper ranking.</li></ul></section></main>")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<main class=\"mx-6 my-8\"><h2 class=\"text-2xl font-bold\">FAQs</h2><section class=\"my-4\"><h3 class=\"text-lg font-semibold\">What is Office Table Tennis?</h3><p>An online tracker for table tennis. You can create an office which is a space you can invite others to. Each office has it's own ranking system.</p></section><section class=\"my-4\"><h3 class=\"text-lg font-semibold\">How our ELOs calculated?</h3><p>Every player starts on 400 ELO points. When a match is played, an expected score is calculated with the two players ELOs (in the case of a team game, the team's ELO is the average of each of the players)...</p><div class=\"flex justify-center items-center gap-2 my-4\"><span>Expected=</span><div><p class=\"text-center mx-auto\">1</p><hr><p class=\"text-center mx-auto\">1 + 10<sup>(loserElo-winnerElo)/400</sup></p></div></div><p>...then the ELO gained/lost can be calculated with the following...</p><div class=\"flex justify-center my-4\">&pm; ELO = 32 * (1 - Expected)</div><p>Before applying ELO to the participants, there are a couple of checks:</p><ul class=\"list-disc my-4\"><li>Minimum ELO is 200. If applying the ELO puts a player below that, they just stay at 200.</li><li>A players first 20 matches are double points (gain and loss) to move them more quickly to their proper ranking.</li></ul><p>Offices can also choose to weight the points by the score of the match. When a score is recorded the points are multiplied by ln(point difference + 1) &times; 2.2 / (ELO difference &times; 0.001 + 2.2), so a thrashing is worth more than a close match, but less so when the favourite was expected to win big.</p><p>These are the defaults. An office admin can change the rating system and its numbers from the office settings, and every match will be recalculated under the new rules.</p></section><section class=\"my-4\"><h3 class=\"text-lg font-semibold\">What does the ? next to a player mean?</h3><p>The player's rating is provisional, the rating system isn't confident in it yet. With ELO this is a player's first 20 matches. Offices using Glicko-2 or TrueSkill also track a rating deviation (&pm;) for each player, which shrinks as they play. With Glicko-2 it also grows while they are inactive. A player is provisional while their deviation is high.</p></section><section class=\"my-4\"><h3 class=\"text-lg font-semibold\">How are doubles rated with TrueSkill?</h3><p>A team's performance is the sum of its players' performances, so in a 2v1 the pair are expected to win and gain little for doing so, while the single player gains a lot for an upset. The points won or lost are shared out by how uncertain each player's rating is, so a newcomer moves a lot while an established partner barely moves.</p></section></main>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
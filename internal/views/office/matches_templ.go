@@ -9,6 +9,7 @@ import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
 import (
+	"fmt"
 	"github.com/RowMur/office-table-tennis/internal/db"
 	"github.com/RowMur/office-table-tennis/internal/gameprocessor"
 	"github.com/RowMur/office-table-tennis/internal/views/components"
@@ -16,11 +17,13 @@ import (
 )
 
 type MatchesPageProps struct {
-	User          *db.User
-	Matches       []db.Match
-	Office        db.Office
-	NextPage      string
-	ProcessedGame *gameprocessor.Game
+	User     *db.User
+	Matches  []db.Match
+	Office   db.Office
+	Game     db.Game
+	NextPage string
+	Points   gameprocessor.MatchPoints
+	Season   *db.Season
 }
 
 func MatchesPage(props MatchesPageProps) templ.Component {
@@ -62,23 +65,25 @@ func MatchesPage(props MatchesPageProps) templ.Component {
 			}
 			templ_7745c5c3_Err = GamePageHeading(GamePageHeadingProps{
 				Office: props.Office,
+				Game:   &props.Game,
+				URL:    props.Office.Link() + "/matches",
 			}).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<section class=\"my-6\">")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<section class=\"my-6\"><div class=\"flex justify-between mb-2 items-center\"><h3 class=\"text-lg font-semibold\">Matches</h3>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = components.SectionHeading("Matches", nil).Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = SeasonSelect(props.Office.Seasons, props.Season, props.Game, props.Office.Link()+"/matches").Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<ul class=\"flex flex-col gap-2\">")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div><ul class=\"flex flex-col gap-2\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = Matches(MatchesProps{Matches: props.Matches, NextPage: props.NextPage, ProcessedGame: props.ProcessedGame, Office: props.Office}).Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = Matches(MatchesProps{Matches: props.Matches, NextPage: props.NextPage, Points: props.Points, Office: props.Office, Game: props.Game, Season: props.Season, CanEdit: props.User.ID == props.Office.AdminRefer}).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -97,10 +102,14 @@ func MatchesPage(props MatchesPageProps) templ.Component {
 }
 
 type MatchesProps struct {
-	Matches       []db.Match
-	NextPage      string
-	ProcessedGame *gameprocessor.Game
-	Office        db.Office
+	Matches  []db.Match
+	NextPage string
+	Points   gameprocessor.MatchPoints
+	Office   db.Office
+	Game     db.Game
+	Season   *db.Season
+	// Whether the matches link to where the office admin can edit them
+	CanEdit bool
 }
 
 func Matches(props MatchesProps) templ.Component {
@@ -137,9 +146,9 @@ func Matches(props MatchesProps) templ.Component {
 					return templ_7745c5c3_Err
 				}
 				var templ_7745c5c3_Var4 string
-				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(props.Office.Link() + "/matches?page=" + props.NextPage)
+				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(GameURL(props.Office, props.Game, "/matches") + "&page=" + props.NextPage + "&season=" + SeasonParam(props.Season))
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/matches.templ`, Line: 48, Col: 68}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/matches.templ`, Line: 60, Col: 127}
 				}
 				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
 				if templ_7745c5c3_Err != nil {
@@ -154,9 +163,33 @@ func Matches(props MatchesProps) templ.Component {
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = components.Match(match, false, props.ProcessedGame).Render(ctx, templ_7745c5c3_Buffer)
-			if templ_7745c5c3_Err != nil {
-				return templ_7745c5c3_Err
+			if props.CanEdit {
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<a href=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var5 templ.SafeURL = templ.SafeURL(fmt.Sprintf("%s/matches/%d/edit", props.Office.Link(), match.ID))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(string(templ_7745c5c3_Var5)))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = components.Match(match, false, props.Points).Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</a>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				templ_7745c5c3_Err = components.Match(match, false, props.Points).Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
 			}
 			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div>")
 			if templ_7745c5c3_Err != nil {
@@ -16,7 +16,7 @@ import (
 	"strconv"
 )
 
-func PendingMatchesPage(office db.Office, pendingMatches []db.Match, user *db.User) templ.Component {
+func PendingMatchesPage(office db.Office, game db.Game, pendingMatches []db.Match, user *db.User) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -62,11 +62,20 @@ func PendingMatchesPage(office db.Office, pendingMatches []db.Match, user *db.Us
 			}
 			templ_7745c5c3_Err = GamePageHeading(GamePageHeadingProps{
 				Office: office,
+				Game:   &game,
+				URL:    office.Link() + "/pending",
 			}).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<section class=\"my-6\"><h4 class=\"text-lg font-semibold mb-2\">Pending Matches</h4>")
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<section class=\"my-6\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = components.SectionHeading("Pending Matches", &components.SecondaryLinkProps{
+				Name: "Disputed",
+				URL:  GameURL(office, game, "/disputed"),
+			}).Render(ctx, templ_7745c5c3_Buffer)
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
@@ -122,4 +131,114 @@ func PendingMatchesPage(office db.Office, pendingMatches []db.Match, user *db.Us
 	})
 }
 
+type PendingMatchSettingsFormData struct {
+	Days        string
+	AutoApprove bool
+}
+
+type PendingMatchSettingsFormErrors struct {
+	Days string
+}
+
+func (e PendingMatchSettingsFormErrors) Any() bool {
+	return e != PendingMatchSettingsFormErrors{}
+}
+
+func NewPendingMatchSettingsFormData(office db.Office) PendingMatchSettingsFormData {
+	return PendingMatchSettingsFormData{
+		Days:        strconv.Itoa(office.PendingMatches.Days),
+		AutoApprove: office.PendingMatches.AutoApprove,
+	}
+}
+
+func ParsePendingMatchSettingsForm(data PendingMatchSettingsFormData) (db.PendingMatchSettings, PendingMatchSettingsFormErrors) {
+	errs := PendingMatchSettingsFormErrors{}
+	settings := db.PendingMatchSettings{
+		Days:        parseIntSetting(data.Days, 0, &errs.Days),
+		AutoApprove: data.AutoApprove,
+	}
+
+	return settings, errs
+}
+
+func PendingMatchSettingsForm(office db.Office, data PendingMatchSettingsFormData, errors PendingMatchSettingsFormErrors, didUpdateSuccessfully *bool) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var4 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var4 == nil {
+			templ_7745c5c3_Var4 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<form hx-post=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var5 string
+		templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(office.Link() + "/settings/pending")
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/pending_matches.templ`, Line: 75, Col: 52}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\" hx-swap=\"outerHTML\" class=\"flex flex-col gap-2\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "days",
+			Label:     "Days a match can wait for approval (0 to wait forever)",
+			InputType: "number",
+			Value:     data.Days,
+			Error:     errors.Days,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.Checkbox(components.CheckboxProps{
+			Name:    "autoApprove",
+			Label:   "Approve matches the creator's side has approved, rather than expiring them",
+			Checked: data.AutoApprove,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<button type=\"submit\" class=\"bg-accent text-light block mx-auto mt-4 px-4 py-1\">Save</button> ")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if didUpdateSuccessfully == nil {
+		} else if *didUpdateSuccessfully {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<p class=\"text-green-500 text-center\">Updated successfully</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<p class=\"text-red-500 text-center\">Failed to update</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</form>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return templ_7745c5c3_Err
+	})
+}
+
 var _ = templruntime.GeneratedTemplate
@@ -0,0 +1,488 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.2.778
+package games
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+import (
+	"fmt"
+	"github.com/RowMur/office-table-tennis/internal/db"
+	"github.com/RowMur/office-table-tennis/internal/views/components"
+	"github.com/RowMur/office-table-tennis/internal/views/layout"
+	"strconv"
+)
+
+func SettingsPage(office db.Office, game db.Game, user *db.User, data SettingsFormData) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<main class=\"mx-6 my-8\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = components.Breadcrumbs([]components.Crumb{
+				{Name: office.Name, URL: office.Link()},
+				{Name: "Settings"},
+			}).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<section class=\"my-6\"><div class=\"flex justify-between mb-2 items-center\"><h4 class=\"text-lg font-semibold\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(game.Name)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/settings.templ`, Line: 20, Col: 50}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" Settings</h4>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = GameSelect(office, game, office.Link()+"/settings").Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</div><p class=\"opacity-70 mb-4\">Changing these will recalculate every match of the game that has been played under the new rules.</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = SettingsForm(office, game, data, SettingsFormErrors{}, nil).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</section><section class=\"my-6\"><h4 class=\"text-lg font-semibold mb-2\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var4 string
+			templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(game.Name)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/settings.templ`, Line: 27, Col: 54}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" Ladder</h4>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = LadderSettingsForm(office, game, NewLadderSettingsFormData(game), LadderSettingsFormErrors{}, nil).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</section><section class=\"my-6\"><h4 class=\"text-lg font-semibold mb-2\">Pending Matches</h4><p class=\"opacity-70 mb-4\">Players are warned a couple of days before a match that hasn't been approved is settled for them.</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = PendingMatchSettingsForm(office, NewPendingMatchSettingsFormData(office), PendingMatchSettingsFormErrors{}, nil).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</section>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = GamesSection(office, GameFormData{}, GameFormErrors{}).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = SeasonsSection(office, office.Seasons, SeasonFormData{}, SeasonFormErrors{}).Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</main>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return templ_7745c5c3_Err
+		})
+		templ_7745c5c3_Err = layout.Base(user).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return templ_7745c5c3_Err
+	})
+}
+
+type SettingsFormData struct {
+	RatingSystem          string
+	StartingPoints        string
+	KFactor               string
+	PointsFloor           string
+	ProvisionalMatches    string
+	ProvisionalMultiplier string
+	HandicapMultiplier    string
+	InactivityWeeks       string
+	GameTarget            string
+	WinByTwo              bool
+	MarginOfVictory       string
+}
+
+type SettingsFormErrors struct {
+	RatingSystem          string
+	StartingPoints        string
+	KFactor               string
+	PointsFloor           string
+	ProvisionalMatches    string
+	ProvisionalMultiplier string
+	HandicapMultiplier    string
+	InactivityWeeks       string
+	MarginOfVictory       string
+	GameTarget            string
+}
+
+func (e SettingsFormErrors) Any() bool {
+	return e != SettingsFormErrors{}
+}
+
+var RatingSystemNames = map[string]string{
+	db.RatingSystemElo:       "ELO",
+	db.RatingSystemGlicko:    "Glicko-2",
+	db.RatingSystemTrueSkill: "TrueSkill",
+}
+
+func NewSettingsFormData(game db.Game) SettingsFormData {
+	settings := game.RatingSettings
+	return SettingsFormData{
+		RatingSystem:          game.RatingSystem,
+		StartingPoints:        strconv.Itoa(settings.StartingPoints),
+		KFactor:               strconv.Itoa(settings.KFactor),
+		PointsFloor:           strconv.Itoa(settings.PointsFloor),
+		ProvisionalMatches:    strconv.Itoa(settings.ProvisionalMatches),
+		ProvisionalMultiplier: strconv.FormatFloat(settings.ProvisionalMultiplier, 'f', -1, 64),
+		HandicapMultiplier:    strconv.FormatFloat(settings.HandicapMultiplier, 'f', -1, 64),
+		InactivityWeeks:       strconv.Itoa(settings.InactivityWeeks),
+		GameTarget:            strconv.Itoa(game.GameTarget),
+		WinByTwo:              game.WinByTwo,
+		MarginOfVictory:       strconv.FormatFloat(settings.MarginOfVictory, 'f', -1, 64),
+	}
+}
+
+func parseIntSetting(value string, min int, errMsg *string) int {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		*errMsg = "Must be a whole number"
+		return 0
+	}
+	if parsed < min {
+		*errMsg = fmt.Sprintf("Must be at least %d", min)
+	}
+	return parsed
+}
+
+func parseFloatSetting(value string, min float64, errMsg *string) float64 {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		*errMsg = "Must be a number"
+		return 0
+	}
+	if parsed < min {
+		*errMsg = fmt.Sprintf("Must be at least %g", min)
+	}
+	return parsed
+}
+
+func ParseSettingsForm(data SettingsFormData) (db.RatingSettings, int, SettingsFormErrors) {
+	errs := SettingsFormErrors{}
+	if _, ok := RatingSystemNames[data.RatingSystem]; !ok {
+		errs.RatingSystem = "Unknown rating system"
+	}
+
+	settings := db.RatingSettings{
+		StartingPoints:        parseIntSetting(data.StartingPoints, 1, &errs.StartingPoints),
+		KFactor:               parseIntSetting(data.KFactor, 1, &errs.KFactor),
+		PointsFloor:           parseIntSetting(data.PointsFloor, 0, &errs.PointsFloor),
+		ProvisionalMatches:    parseIntSetting(data.ProvisionalMatches, 0, &errs.ProvisionalMatches),
+		ProvisionalMultiplier: parseFloatSetting(data.ProvisionalMultiplier, 1, &errs.ProvisionalMultiplier),
+		HandicapMultiplier:    parseFloatSetting(data.HandicapMultiplier, 0, &errs.HandicapMultiplier),
+		InactivityWeeks:       parseIntSetting(data.InactivityWeeks, 1, &errs.InactivityWeeks),
+		MarginOfVictory:       parseFloatSetting(data.MarginOfVictory, 0, &errs.MarginOfVictory),
+	}
+
+	if errs.MarginOfVictory == "" && settings.MarginOfVictory > 1 {
+		errs.MarginOfVictory = "Must be at most 1"
+	}
+
+	if errs.PointsFloor == "" && errs.StartingPoints == "" && settings.PointsFloor >= settings.StartingPoints {
+		errs.PointsFloor = "Must be below the starting points"
+	}
+
+	gameTarget := parseIntSetting(data.GameTarget, 0, &errs.GameTarget)
+
+	return settings, gameTarget, errs
+}
+
+func SettingsForm(office db.Office, game db.Game, data SettingsFormData, errors SettingsFormErrors, didUpdateSuccessfully *bool) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var5 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var5 == nil {
+			templ_7745c5c3_Var5 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<form hx-post=\"")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var6 string
+		templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinStringErrs(office.Link() + "/settings")
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/settings.templ`, Line: 150, Col: 44}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\" hx-swap=\"outerHTML\" class=\"flex flex-col gap-2\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = GameInput(game).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<label for=\"ratingSystem\" class=\"block\">Rating system:</label> <select name=\"ratingSystem\" id=\"ratingSystem\" class=\"bg-light px-2 py-1 rounded-md\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		for _, ratingSystem := range []string{db.RatingSystemElo, db.RatingSystemGlicko, db.RatingSystemTrueSkill} {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<option value=\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var7 string
+			templ_7745c5c3_Var7, templ_7745c5c3_Err = templ.JoinStringErrs(ratingSystem)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/settings.templ`, Line: 156, Col: 25}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var7))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("\"")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if ratingSystem == data.RatingSystem {
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" selected")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var8 string
+			templ_7745c5c3_Var8, templ_7745c5c3_Err = templ.JoinStringErrs(RatingSystemNames[ratingSystem])
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/settings.templ`, Line: 160, Col: 38}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var8))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</option>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</select> ")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if errors.RatingSystem != "" {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<p class=\"text-red-500\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var9 string
+			templ_7745c5c3_Var9, templ_7745c5c3_Err = templ.JoinStringErrs(errors.RatingSystem)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `internal/views/office/settings.templ`, Line: 164, Col: 48}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var9))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "startingPoints",
+			Label:     "Starting points",
+			InputType: "number",
+			Value:     data.StartingPoints,
+			Error:     errors.StartingPoints,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "kFactor",
+			Label:     "K-factor (ELO)",
+			InputType: "number",
+			Value:     data.KFactor,
+			Error:     errors.KFactor,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "pointsFloor",
+			Label:     "Minimum points (ELO)",
+			InputType: "number",
+			Value:     data.PointsFloor,
+			Error:     errors.PointsFloor,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "provisionalMatches",
+			Label:     "Provisional matches (ELO)",
+			InputType: "number",
+			Value:     data.ProvisionalMatches,
+			Error:     errors.ProvisionalMatches,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "provisionalMultiplier",
+			Label:     "Provisional multiplier (ELO)",
+			InputType: "text",
+			Value:     data.ProvisionalMultiplier,
+			Error:     errors.ProvisionalMultiplier,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "handicapMultiplier",
+			Label:     "Handicap multiplier (ELO)",
+			InputType: "text",
+			Value:     data.HandicapMultiplier,
+			Error:     errors.HandicapMultiplier,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "inactivityWeeks",
+			Label:     "Weeks until a player is inactive",
+			InputType: "number",
+			Value:     data.InactivityWeeks,
+			Error:     errors.InactivityWeeks,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "marginOfVictory",
+			Label:     "How much the match score counts, 0 to 1 (ELO)",
+			InputType: "text",
+			Value:     data.MarginOfVictory,
+			Error:     errors.MarginOfVictory,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.FormField(components.FormFieldProps{
+			Name:      "gameTarget",
+			Label:     "Points to win a game (0 if there's no target)",
+			InputType: "number",
+			Value:     data.GameTarget,
+			Error:     errors.GameTarget,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = components.Checkbox(components.CheckboxProps{
+			Name:    "winByTwo",
+			Label:   "Games must be won by 2 points",
+			Checked: data.WinByTwo,
+		}).Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<button type=\"submit\" class=\"bg-accent text-light block mx-auto mt-4 px-4 py-1\">Save</button> ")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if didUpdateSuccessfully == nil {
+		} else if *didUpdateSuccessfully {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<p class=\"text-green-500 text-center\">Updated successfully</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else {
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("<p class=\"text-red-500 text-center\">Failed to update</p>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString("</form>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return templ_7745c5c3_Err
+	})
+}
+
+var _ = templruntime.GeneratedTemplate